@@ -0,0 +1,78 @@
+// Command import-contacts bulk-imports contacts from a CSV file with name,
+// email and message columns, printing a per-row JSON report.
+//
+//	go run ./cmd/import-contacts -file signups.csv -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the CSV file to import")
+	dryRun := flag.Bool("dry-run", false, "report what would happen without writing anything")
+	subscribe := flag.Bool("subscribe", false, "queue created contacts to be added to the Mailchimp list; needs a consent column")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.GetConfig()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(cfg.MongoDatabase)
+	contactRepo := repositories.NewMongoContactRepository(db)
+	personRepo := repositories.NewMongoPersonRepository(db)
+	contactService := services.NewContactService(contactRepo, personRepo)
+	mailchimpSync := services.NewMailchimpSyncService(contactRepo, services.NewNotificationService(cfg), cfg)
+	consentService := services.NewConsentService(repositories.NewMongoConsentRepository(db), personRepo, cfg)
+	importService := services.NewImportService(contactService, mailchimpSync, consentService)
+
+	report, err := importService.ImportCSV(ctx, f, services.ImportOptions{
+		DryRun:    *dryRun,
+		Subscribe: *subscribe,
+	})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	log.Printf("created=%d duplicates=%d invalid=%d failed=%d subscribed=%d dry_run=%t",
+		report.Created, report.Duplicates, report.Invalid, report.Failed, report.Subscribed, report.DryRun)
+	if report.Invalid > 0 || report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -4,12 +4,18 @@ import LoginButton from './components/LoginButton';
 import VerificationPage from './components/VerificationPage';
 import AdminPage from './components/AdminPage';
 import LandingPage from './components/LandingPage';
+import BookingForm from './components/BookingForm';
+import SubscriptionConfirmPage from './components/SubscriptionConfirmPage';
+import PrivacyPage from './components/PrivacyPage';
 
 function App() {
   return (
     <Router>
       <Routes>
         <Route path="/" element={<LandingPage />} />
+        <Route path="/booking" element={<BookingForm />} />
+        <Route path="/subscribe/confirm" element={<SubscriptionConfirmPage />} />
+        <Route path="/privacy" element={<PrivacyPage />} />
         <Route path="/verify" element={<VerificationPage />} />
         <Route path="/admin" element={<AdminPage />} />
         <Route path="*" element={
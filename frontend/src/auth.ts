@@ -0,0 +1,73 @@
+import axios, { AxiosError, InternalAxiosRequestConfig } from 'axios';
+
+const apiBase = `${import.meta.env.VITE_API_BASE_ADDRESS}/api`;
+
+interface SessionTokens {
+  token: string;
+  refresh_token: string;
+}
+
+export const saveSession = (tokens: SessionTokens) => {
+  localStorage.setItem('token', tokens.token);
+  localStorage.setItem('refreshToken', tokens.refresh_token);
+};
+
+export const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refreshToken');
+};
+
+// Refresh tokens can only be used once, so concurrent 401s share a single
+// refresh.
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = (): Promise<string | null> => {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshing = (refreshToken
+      ? axios
+          .post<SessionTokens>(`${apiBase}/auth/refresh`, { refresh_token: refreshToken })
+          .then((response) => {
+            saveSession(response.data);
+            return response.data.token;
+          })
+          .catch(() => {
+            clearSession();
+            return null;
+          })
+      : Promise.resolve(null)
+    ).finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// Retry admin requests once with a fresh access token when theirs expires.
+axios.interceptors.response.use(undefined, async (error: AxiosError) => {
+  const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+  if (error.response?.status !== 401 || !config || config._retried || !config.headers?.Authorization) {
+    throw error;
+  }
+
+  const token = await refreshAccessToken();
+  if (!token) {
+    throw error;
+  }
+  config._retried = true;
+  config.headers.Authorization = `Bearer ${token}`;
+  return axios.request(config);
+});
+
+export const logout = async () => {
+  const token = localStorage.getItem('token');
+  try {
+    if (token) {
+      await axios.post(`${apiBase}/auth/logout`, undefined, {
+        headers: { Authorization: `Bearer ${token}` },
+      });
+    }
+  } finally {
+    clearSession();
+  }
+};
@@ -2,6 +2,7 @@ import React from 'react';
 import { Container, Box, Typography, Table, TableBody, TableCell, TableContainer, TableHead, TableRow, Paper, Button } from '@mui/material';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import { logout } from '../auth';
 
 interface Contact {
   ID: number;
@@ -11,23 +12,35 @@ interface Contact {
   CreatedAt: string;
 }
 
+interface ContactPage {
+  contacts: Contact[];
+  total: number;
+  next_cursor: string;
+}
+
 const AdminPage = () => {
   const navigate = useNavigate();
   const [contacts, setContacts] = React.useState<Contact[]>([]);
+  const [total, setTotal] = React.useState(0);
+  const [nextCursor, setNextCursor] = React.useState('');
   const [loading, setLoading] = React.useState(true);
   const [error, setError] = React.useState<string | null>(null);
 
   const token = localStorage.getItem('token');
 
-  const fetchContacts = async () => {
+  const fetchContacts = async (cursor = '') => {
     try {
       setLoading(true);
-      const response = await axios.get<Contact[]>(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contacts`, {
+      const response = await axios.get<ContactPage>(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contacts`, {
         headers: {
-          Authorization: `Bearer ${token}`
-        }
+          Authorization: `Bearer ${localStorage.getItem('token')}`
+        },
+        params: cursor ? { cursor } : undefined
       });
-      setContacts(response.data ?? []);
+      const page = response.data.contacts ?? [];
+      setContacts(cursor ? [...contacts, ...page] : page);
+      setTotal(response.data.total);
+      setNextCursor(response.data.next_cursor);
     } catch (error) {
       console.error('Error fetching contacts:', error as Error);
       setError((error as Error).message);
@@ -49,7 +62,7 @@ const AdminPage = () => {
     try {
       await axios.delete(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contacts/${id}`, {
         headers: {
-          Authorization: `Bearer ${token}`
+          Authorization: `Bearer ${localStorage.getItem('token')}`
         }
       });
       await fetchContacts();
@@ -68,8 +81,8 @@ const AdminPage = () => {
         <Button
           variant="outlined"
           color="secondary"
-          onClick={() => {
-            localStorage.removeItem('token');
+          onClick={async () => {
+            await logout().catch(() => undefined);
             localStorage.removeItem('adminPhoneNumber');
             navigate('/');
           }}
@@ -122,6 +135,19 @@ const AdminPage = () => {
           </Table>
         </TableContainer>
       )}
+
+      {!loading && !error && (
+        <Box sx={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', mt: 2 }}>
+          <Typography variant="body2">
+            Showing {contacts.length} of {total}
+          </Typography>
+          {nextCursor && (
+            <Button variant="outlined" onClick={() => fetchContacts(nextCursor)}>
+              Load more
+            </Button>
+          )}
+        </Box>
+      )}
     </Container>
   );
 };
@@ -0,0 +1,279 @@
+import React, { useEffect, useState } from 'react';
+import {
+  Box,
+  Button,
+  Container,
+  MenuItem,
+  TextField,
+  Typography,
+  Alert
+} from '@mui/material';
+import { useForm } from 'react-hook-form';
+import { zodResolver } from '@hookform/resolvers/zod';
+import { z } from 'zod';
+import axios from 'axios';
+
+const eventTypes = [
+  { value: 'wedding', label: 'Wedding' },
+  { value: 'private', label: 'Private party' },
+  { value: 'corporate', label: 'Corporate event' },
+  { value: 'festival', label: 'Festival' },
+  { value: 'club', label: 'Club or bar' },
+  { value: 'other', label: 'Other' },
+];
+
+const bookingSchema = z.object({
+  name: z.string()
+    .min(2, 'Name must be at least 2 characters')
+    .max(100, 'Name must be at most 100 characters'),
+  email: z.string()
+    .email('Email must be valid')
+    .min(1, 'Email is required'),
+  phone: z.string()
+    .max(30, 'Phone must be at most 30 characters')
+    .optional(),
+  event_type: z.string().min(1, 'Event type is required'),
+  event_date: z.string().min(1, 'Event date is required'),
+  venue: z.string()
+    .min(1, 'Venue is required')
+    .max(200, 'Venue must be at most 200 characters'),
+  city: z.string()
+    .min(1, 'City is required')
+    .max(100, 'City must be at most 100 characters'),
+  capacity: z.string()
+    .regex(/^\d*$/, 'Capacity must be a number')
+    .optional(),
+  budget: z.string()
+    .max(100, 'Budget must be at most 100 characters')
+    .optional(),
+  message: z.string()
+    .max(2000, 'Message must be at most 2000 characters')
+    .optional(),
+  website: z.string().optional(),
+});
+
+type BookingFormInputs = z.infer<typeof bookingSchema>;
+
+const BookingForm = () => {
+  const [success, setSuccess] = useState(false);
+  const [error, setError] = useState('');
+  const [formToken, setFormToken] = useState('');
+
+  const loadFormToken = () => {
+    axios.get(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contact-form-token`)
+      .then((response) => setFormToken(response.data.token))
+      .catch(() => setFormToken(''));
+  };
+
+  useEffect(loadFormToken, []);
+
+  const {
+    register,
+    handleSubmit,
+    formState: { errors, isSubmitting },
+    reset,
+  } = useForm<BookingFormInputs>({
+    resolver: zodResolver(bookingSchema),
+    defaultValues: {
+      name: '',
+      email: '',
+      phone: '',
+      event_type: '',
+      event_date: '',
+      venue: '',
+      city: '',
+      capacity: '',
+      budget: '',
+      message: '',
+      website: '',
+    },
+  });
+
+  const onSubmit = async (data: BookingFormInputs) => {
+    try {
+      const response = await axios.post(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/bookings`, {
+        ...data,
+        capacity: data.capacity ? Number(data.capacity) : 0,
+        form_token: formToken,
+      });
+      if (response.status === 201) {
+        setSuccess(true);
+        reset();
+        loadFormToken();
+      }
+    } catch (err) {
+      // Each form token can only be submitted once
+      loadFormToken();
+      if (axios.isAxiosError(err) && err.response?.data?.error) {
+        setError(err.response.data.error);
+      } else {
+        setError((err as Error).message || 'An error occurred');
+      }
+    }
+  };
+
+  return (
+    <Container maxWidth="sm">
+      <Box sx={{ mt: 4, mb: 4 }}>
+        <Typography variant="h4" component="h1" gutterBottom>
+          Book Chanterelle
+        </Typography>
+
+        {success && (
+          <Alert
+            onClose={() => setSuccess(false)}
+            severity="success" sx={{ mb: 2 }}
+          >
+            Thanks! We've received your booking inquiry and will be in touch soon.
+          </Alert>
+        )}
+
+        {error && (
+          <Alert
+            onClose={() => setError('')}
+            severity="error" sx={{ mb: 2 }}
+          >
+            {error}
+          </Alert>
+        )}
+        {!success
+          ? (
+            <form onSubmit={handleSubmit(onSubmit)}>
+              <TextField
+                fullWidth
+                id="name"
+                label="Name"
+                {...register('name')}
+                error={!!errors.name}
+                helperText={errors.name?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="email"
+                label="Email"
+                type="email"
+                {...register('email')}
+                error={!!errors.email}
+                helperText={errors.email?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="phone"
+                label="Phone"
+                type="tel"
+                {...register('phone')}
+                error={!!errors.phone}
+                helperText={errors.phone?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                select
+                id="event_type"
+                label="Event type"
+                defaultValue=""
+                inputProps={register('event_type')}
+                error={!!errors.event_type}
+                helperText={errors.event_type?.message}
+                sx={{ mb: 2 }}
+              >
+                {eventTypes.map((type) => (
+                  <MenuItem key={type.value} value={type.value}>
+                    {type.label}
+                  </MenuItem>
+                ))}
+              </TextField>
+
+              <TextField
+                fullWidth
+                id="event_date"
+                label="Event date"
+                type="date"
+                InputLabelProps={{ shrink: true }}
+                {...register('event_date')}
+                error={!!errors.event_date}
+                helperText={errors.event_date?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="venue"
+                label="Venue"
+                {...register('venue')}
+                error={!!errors.venue}
+                helperText={errors.venue?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="city"
+                label="City"
+                {...register('city')}
+                error={!!errors.city}
+                helperText={errors.city?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="capacity"
+                label="Expected attendance"
+                inputMode="numeric"
+                {...register('capacity')}
+                error={!!errors.capacity}
+                helperText={errors.capacity?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="budget"
+                label="Budget"
+                {...register('budget')}
+                error={!!errors.budget}
+                helperText={errors.budget?.message}
+                sx={{ mb: 2 }}
+              />
+
+              <TextField
+                fullWidth
+                id="message"
+                label="Anything else we should know?"
+                multiline
+                rows={4}
+                {...register('message')}
+                error={!!errors.message}
+                helperText={errors.message?.message}
+                sx={{ mb: 2 }}
+              />
+
+              {/* Honeypot: hidden from people, filled in by bots */}
+              <Box aria-hidden="true" sx={{ position: 'absolute', left: '-10000px', width: 1, height: 1, overflow: 'hidden' }}>
+                <input type="text" tabIndex={-1} autoComplete="off" {...register('website')} />
+              </Box>
+
+              <Button
+                type="submit"
+                variant="contained"
+                color="info"
+                fullWidth
+                disabled={isSubmitting}
+              >
+                {isSubmitting ? 'Sending...' : 'Send Inquiry'}
+              </Button>
+            </form>
+          )
+          : null}
+      </Box>
+    </Container>
+  );
+};
+
+export default BookingForm;
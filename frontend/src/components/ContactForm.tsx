@@ -1,8 +1,11 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import {
   Box,
   Button,
+  Checkbox,
   Container,
+  FormControlLabel,
+  MenuItem,
   TextField,
   Typography,
   Alert
@@ -12,6 +15,14 @@ import { zodResolver } from '@hookform/resolvers/zod';
 import { z } from 'zod';
 import axios from 'axios';
 
+const categories = [
+  { value: 'general', label: 'General' },
+  { value: 'fan_mail', label: 'Fan mail' },
+  { value: 'booking', label: 'Booking' },
+  { value: 'press', label: 'Press' },
+  { value: 'licensing', label: 'Licensing' },
+];
+
 const contactSchema = z.object({
   name: z.string()
     .min(2, 'Name must be at least 2 characters')
@@ -19,9 +30,12 @@ const contactSchema = z.object({
   email: z.string()
     .email('Email must be valid')
     .min(1, 'Email is required'),
+  category: z.string(),
   message: z.string()
     .max(500, 'Message must be at most 500 characters')
     .optional(),
+  subscribe: z.boolean(),
+  website: z.string().optional(),
 });
 
 type ContactFormInputs = z.infer<typeof contactSchema>;
@@ -29,6 +43,21 @@ type ContactFormInputs = z.infer<typeof contactSchema>;
 const ContactForm = () => {
   const [success, setSuccess] = useState(false);
   const [error, setError] = useState('');
+  const [formToken, setFormToken] = useState('');
+  const [consentText, setConsentText] = useState('');
+  const [consentVersion, setConsentVersion] = useState('');
+
+  const loadFormToken = () => {
+    axios.get(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contact-form-token`)
+      .then((response) => {
+        setFormToken(response.data.token);
+        setConsentText(response.data.consent_text || '');
+        setConsentVersion(response.data.consent_version || '');
+      })
+      .catch(() => setFormToken(''));
+  };
+
+  useEffect(loadFormToken, []);
 
   const {
     register,
@@ -40,19 +69,29 @@ const ContactForm = () => {
     defaultValues: {
       name: '',
       email: '',
+      category: 'general',
       message: '',
+      subscribe: false,
+      website: '',
     },
   });
 
   const onSubmit = async (data: ContactFormInputs) => {
     try {
-      const response = await axios.post(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contacts`, data);
+      const response = await axios.post(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/contacts`, {
+        ...data,
+        form_token: formToken,
+        consent_version: consentVersion,
+      });
       if (response.status === 201) {
         setSuccess(response.data.message || 'Your message has been sent successfully!');
         reset();
+        loadFormToken();
       }
     } catch (err) {
       setError((err as Error).message || 'An error occurred');
+      // Each form token can only be submitted once
+      loadFormToken();
     }
   };
 
@@ -104,6 +143,22 @@ const ContactForm = () => {
                 sx={{ mb: 2 }}
               />
 
+              <TextField
+                fullWidth
+                select
+                id="category"
+                label="What is this about?"
+                defaultValue="general"
+                inputProps={register('category')}
+                sx={{ mb: 2 }}
+              >
+                {categories.map((category) => (
+                  <MenuItem key={category.value} value={category.value}>
+                    {category.label}
+                  </MenuItem>
+                ))}
+              </TextField>
+
               <TextField
                 fullWidth
                 id="message"
@@ -116,6 +171,19 @@ const ContactForm = () => {
                 sx={{ mb: 2 }}
               />
 
+              {consentText && (
+                <FormControlLabel
+                  control={<Checkbox {...register('subscribe')} />}
+                  label={<Typography variant="body2">{consentText}</Typography>}
+                  sx={{ mb: 2 }}
+                />
+              )}
+
+              {/* Honeypot: hidden from people, filled in by bots */}
+              <Box aria-hidden="true" sx={{ position: 'absolute', left: '-10000px', width: 1, height: 1, overflow: 'hidden' }}>
+                <input type="text" tabIndex={-1} autoComplete="off" {...register('website')} />
+              </Box>
+
               <Button
                 type="submit"
                 variant="contained"
@@ -0,0 +1,162 @@
+import React, { useState } from 'react';
+import {
+  Container,
+  Box,
+  Typography,
+  Button,
+  Alert,
+  TextField,
+  RadioGroup,
+  FormControlLabel,
+  Radio,
+} from '@mui/material';
+import { useSearchParams } from 'react-router-dom';
+import axios from 'axios';
+
+type Action = 'export' | 'erase';
+type RequestState = 'idle' | 'working' | 'done' | 'error';
+
+const apiBase = `${import.meta.env.VITE_API_BASE_ADDRESS}/api/privacy/requests`;
+
+const PrivacyPage = () => {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const linkAction: Action = searchParams.get('action') === 'erase' ? 'erase' : 'export';
+
+  const [email, setEmail] = useState('');
+  const [action, setAction] = useState<Action>('export');
+  const [state, setState] = useState<RequestState>('idle');
+  const [error, setError] = useState('');
+
+  const handleRequest = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setState('working');
+    setError('');
+
+    try {
+      await axios.post(apiBase, { email, action });
+      setState('done');
+    } catch (err) {
+      setState('error');
+      if (axios.isAxiosError(err) && err.response?.status === 429) {
+        setError('Too many requests. Please try again later.');
+      } else {
+        setError('We could not take your request. Please try again later.');
+      }
+    }
+  };
+
+  const handleExport = async () => {
+    setState('working');
+    setError('');
+
+    try {
+      const response = await axios.post(`${apiBase}/export`, { token }, { responseType: 'blob' });
+      const url = window.URL.createObjectURL(response.data);
+      const link = document.createElement('a');
+      link.href = url;
+      link.download = 'chanterelle-personal-data.json';
+      link.click();
+      window.URL.revokeObjectURL(url);
+      setState('done');
+    } catch {
+      setState('error');
+      setError('This link is not valid or has expired. Please make a new request.');
+    }
+  };
+
+  const handleErase = async () => {
+    setState('working');
+    setError('');
+
+    try {
+      await axios.post(`${apiBase}/erase`, { token });
+      setState('done');
+    } catch {
+      setState('error');
+      setError('This link is not valid or has expired. Please make a new request.');
+    }
+  };
+
+  return (
+    <Container maxWidth="sm">
+      <Box sx={{ mt: 8, mb: 4 }}>
+        <Typography variant="h4" component="h1" gutterBottom sx={{ textAlign: 'center' }}>
+          Your data
+        </Typography>
+
+        {state === 'error' && (
+          <Alert severity="error" sx={{ mb: 2 }}>
+            {error}
+          </Alert>
+        )}
+
+        {!token && state === 'done' && (
+          <Alert severity="success" sx={{ mb: 2 }}>
+            If we hold any data for that address, we've emailed it a link to continue.
+          </Alert>
+        )}
+
+        {!token && state !== 'done' && (
+          <Box component="form" onSubmit={handleRequest}>
+            <Typography gutterBottom>
+              Ask for a copy of the data we hold about you, or ask us to delete it. We'll email
+              a link to the address you enter to make sure it's yours.
+            </Typography>
+            <TextField
+              fullWidth
+              required
+              type="email"
+              label="Email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              margin="normal"
+            />
+            <RadioGroup value={action} onChange={(e) => setAction(e.target.value as Action)}>
+              <FormControlLabel value="export" control={<Radio />} label="Send me a copy of my data" />
+              <FormControlLabel value="erase" control={<Radio />} label="Delete my data" />
+            </RadioGroup>
+            <Button type="submit" variant="contained" color="info" disabled={state === 'working'} sx={{ mt: 2 }}>
+              {state === 'working' ? 'Sending...' : 'Email me a link'}
+            </Button>
+          </Box>
+        )}
+
+        {token && linkAction === 'export' && (
+          <Box sx={{ textAlign: 'center' }}>
+            {state === 'done' && (
+              <Alert severity="success" sx={{ mb: 2 }}>
+                Your data has been downloaded.
+              </Alert>
+            )}
+            <Button variant="contained" color="info" onClick={handleExport} disabled={state === 'working'}>
+              {state === 'working' ? 'Preparing...' : 'Download my data'}
+            </Button>
+          </Box>
+        )}
+
+        {token && linkAction === 'erase' && (
+          <Box sx={{ textAlign: 'center' }}>
+            {state === 'done' ? (
+              <Alert severity="success" sx={{ mb: 2 }}>
+                Your data has been deleted and you've been removed from the mailing list.
+              </Alert>
+            ) : (
+              <>
+                <Typography gutterBottom>
+                  This permanently deletes your messages, bookings and mailing list subscription.
+                  It can't be undone.
+                </Typography>
+                <Button variant="contained" color="error" onClick={handleErase} disabled={state === 'working'}>
+                  {state === 'working' ? 'Deleting...' : 'Delete my data'}
+                </Button>
+              </>
+            )}
+          </Box>
+        )}
+      </Box>
+    </Container>
+  );
+};
+
+export default PrivacyPage;
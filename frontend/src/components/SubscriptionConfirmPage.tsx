@@ -0,0 +1,71 @@
+import React, { useState } from 'react';
+import { Container, Box, Typography, Button, Alert } from '@mui/material';
+import { useSearchParams } from 'react-router-dom';
+import axios from 'axios';
+
+type ConfirmState = 'idle' | 'confirming' | 'confirmed' | 'error';
+
+const SubscriptionConfirmPage = () => {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [state, setState] = useState<ConfirmState>('idle');
+  const [error, setError] = useState('');
+
+  const handleConfirm = async () => {
+    setState('confirming');
+    setError('');
+
+    try {
+      await axios.post(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/subscriptions/confirm`, { token });
+      setState('confirmed');
+    } catch (err) {
+      setState('error');
+      if (axios.isAxiosError(err) && err.response?.status === 410) {
+        setError('This confirmation link has expired. Please sign up again.');
+      } else {
+        setError('This confirmation link is not valid.');
+      }
+    }
+  };
+
+  return (
+    <Container maxWidth="sm">
+      <Box sx={{ mt: 8, mb: 4, textAlign: 'center' }}>
+        <Typography variant="h4" component="h1" gutterBottom>
+          Join the mailing list
+        </Typography>
+
+        {state === 'confirmed' && (
+          <Alert severity="success" sx={{ mb: 2 }}>
+            You're subscribed. Thanks for joining!
+          </Alert>
+        )}
+
+        {state === 'error' && (
+          <Alert severity="error" sx={{ mb: 2 }}>
+            {error}
+          </Alert>
+        )}
+
+        {!token && (
+          <Alert severity="error" sx={{ mb: 2 }}>
+            This confirmation link is missing its token.
+          </Alert>
+        )}
+
+        {token && state !== 'confirmed' && (
+          <Button
+            variant="contained"
+            color="info"
+            onClick={handleConfirm}
+            disabled={state === 'confirming'}
+          >
+            {state === 'confirming' ? 'Confirming...' : 'Confirm my subscription'}
+          </Button>
+        )}
+      </Box>
+    </Container>
+  );
+};
+
+export default SubscriptionConfirmPage;
@@ -1,12 +1,17 @@
 import React, { useState, useEffect, useRef } from 'react';
-import { Container, Box, Typography, TextField, Button, Alert, Stack } from '@mui/material';
+import { Container, Box, Typography, TextField, Button, Alert, Stack, FormControlLabel, Switch } from '@mui/material';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
+import { saveSession } from '../auth';
 
 const VerificationPage = () => {
   const navigate = useNavigate();
   const [code, setCode] = useState<string>('');
   const [error, setError] = useState('');
+  // Authenticator app and recovery codes are checked instead of the
+  // emailed code.
+  const [useAuthenticator, setUseAuthenticator] = useState(false);
+  const [recoveryCode, setRecoveryCode] = useState('');
 
   const [focusedIndex, setFocusedIndex] = useState(0);
 
@@ -26,7 +31,7 @@ const VerificationPage = () => {
     setError('');
 
     // Validate code format
-    if (!/^[0-9]{6}$/.test(code)) {
+    if (!recoveryCode && !/^[0-9]{6}$/.test(code)) {
       setError('Verification code must be 6 digits');
       return;
     }
@@ -40,12 +45,13 @@ const VerificationPage = () => {
 
       const response = await axios.post(`${import.meta.env.VITE_API_BASE_ADDRESS}/api/verify-code`, {
         email,
-        code: code
+        code: recoveryCode || code,
+        method: useAuthenticator || recoveryCode ? 'totp' : 'email'
       });
 
       if (response.status === 200) {
-        // Store token in localStorage
-        localStorage.setItem('token', response.data.token);
+        // Store the session tokens in localStorage
+        saveSession(response.data);
         navigate('/admin');
       }
     } catch (error) {
@@ -112,11 +118,28 @@ const VerificationPage = () => {
           ))}
         </Box>
 
+        <FormControlLabel
+          control={<Switch checked={useAuthenticator} onChange={(e) => {
+            setUseAuthenticator(e.target.checked);
+            setRecoveryCode('');
+          }} />}
+          label="Use my authenticator app"
+        />
+        {useAuthenticator && (
+          <TextField
+            label="Or a recovery code"
+            value={recoveryCode}
+            onChange={(e) => setRecoveryCode(e.target.value.trim())}
+            size="small"
+            fullWidth
+          />
+        )}
+
         <Button
           variant="contained"
           color="primary"
           onClick={handleVerify}
-          disabled={code.length !== 6}
+          disabled={code.length !== 6 && !recoveryCode}
           fullWidth
           ref={verifyButtonRef}
         >
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -28,20 +30,98 @@ type Config struct {
 	MongoDatabase string
 	JWTSecret     string
 
+	// Admin sessions. Access tokens are short-lived JWTs; refresh tokens
+	// renew them and end the session if unused for RefreshTokenTTL.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// TrashRetention is how long deleted contacts stay in the trash before
+	// they are purged. Zero disables purging.
+	TrashRetention time.Duration
+
+	// Spam protection for the public contact form
+	SpamMinSubmitTime     time.Duration
+	SpamScoreThreshold    int
+	SpamMaxLinks          int
+	SpamBlockedKeywords   []string
+	SpamDisposableDomains []string
+
+	// Rate limiting. Limits are written as "N/duration"; empty disables one.
+	// RateLimitStore is "memory" or "mongo" to share limits between
+	// instances.
+	RateLimitStore             string
+	RateLimitContactIP         string
+	RateLimitContactEmail      string
+	RateLimitVerificationIP    string
+	RateLimitVerificationEmail string
+
+	// TrustedProxies are the proxy IPs or CIDRs whose X-Forwarded-For is
+	// believed when working out a client's IP for rate limits and lockouts.
+	// With none, the connecting address is used. TrustedPlatform names a
+	// header set by the hosting platform to read it from instead.
+	TrustedProxies  []string
+	TrustedPlatform string
+
+	// ConsentText is the mailing list consent wording shown next to the
+	// contact form's signup box and recorded with each signup.
+	ConsentText string
+
+	// DoubleOptIn makes mailing list signups confirm by email before they
+	// are subscribed. Confirmation links point at FrontendURL and lapse after
+	// SubscriptionConfirmWindow.
+	DoubleOptIn               bool
+	SubscriptionConfirmWindow time.Duration
+	FrontendURL               string
+
+	// PrivacySelfService lets people export or erase their own data by
+	// following a link emailed to them. Links point at FrontendURL.
+	PrivacySelfService bool
+
+	// Auto-replies acknowledging form submissions. AutoReplyEnabled is the
+	// kill switch; AutoReplyWindow is how long a sender goes without another
+	// auto-reply after getting one.
+	AutoReplyEnabled bool
+	AutoReplyWindow  time.Duration
+
 	// Verification settings
 	VerificationCodeLength int
 	VerificationCodeExpiry time.Duration
+	// VerificationMaxAttempts wrong guesses, at one code or at any codes
+	// sent within VerificationCodeExpiry, invalidate the code and lock the
+	// email out for VerificationLockout, doubling with each lockout in a row
+	// up to VerificationMaxLockout. An IP with VerificationMaxIPFailures
+	// failures in the last hour is refused; that needs TrustedProxies or
+	// TrustedPlatform, or every client behind a proxy would share one IP.
+	VerificationMaxAttempts   int
+	VerificationLockout       time.Duration
+	VerificationMaxLockout    time.Duration
+	VerificationMaxIPFailures int
 
 	// Mailchimp configuration
 	MailchimpAPIKey string
 	MailchimpListID string
-	AdminEmail      string
+	// MailchimpSyncMaxAttempts is how many times a contact's subscription is
+	// tried before it is marked failed.
+	MailchimpSyncMaxAttempts int
+	AdminEmail               string
 
 	// EmailJS configuration
 	EmailJSServiceID   string
 	EmailJSTemplateID  string
 	EmailJSUserID      string
 	EmailJSAccessToken string
+	// EmailJSReplyTemplateID is the template used for admin replies to
+	// contacts. It falls back to EmailJSTemplateID.
+	EmailJSReplyTemplateID string
+	// EmailJSBookingTemplateID is the template used for booking inquiry
+	// notifications. It falls back to EmailJSTemplateID.
+	EmailJSBookingTemplateID string
+	// EmailJSAutoReplyTemplateID is the auto-reply template for categories
+	// whose routing rule doesn't name one. Empty sends no auto-reply for them.
+	EmailJSAutoReplyTemplateID string
+	// EmailJSConfirmTemplateID is the template for double opt-in
+	// confirmation emails. It falls back to EmailJSTemplateID.
+	EmailJSConfirmTemplateID string
 }
 
 func LoadConfig() (*Config, error) {
@@ -50,19 +130,50 @@ func LoadConfig() (*Config, error) {
 	_ = godotenv.Load() // Ignore errors - will use system env if .env doesn't exist
 
 	config := &Config{
-		Port:                   getEnvAsInt("PORT", 8080),
-		MongoURI:               getEnv("MONGODB_URI", ""),
-		MongoDatabase:          getEnv("MONGODB_DATABASE", ""),
-		JWTSecret:              getEnv("JWT_SECRET", ""),
-		VerificationCodeLength: 6,
-		VerificationCodeExpiry: 15 * time.Minute,
-		MailchimpAPIKey:        getEnv("MAILCHIMP_API_KEY", ""),
-		MailchimpListID:        getEnv("MAILCHIMP_LIST_ID", ""),
-		AdminEmail:             getEnv("ADMIN_EMAIL", ""),
-		EmailJSServiceID:       getEnv("EMAILJS_SERVICE_ID", ""),
-		EmailJSTemplateID:      getEnv("EMAILJS_TEMPLATE_ID", ""),
-		EmailJSUserID:          getEnv("EMAILJS_USER_ID", ""),
-		EmailJSAccessToken:     getEnv("EMAILJS_ACCESS_TOKEN", ""),
+		Port:                       getEnvAsInt("PORT", 8080),
+		MongoURI:                   getEnv("MONGODB_URI", ""),
+		MongoDatabase:              getEnv("MONGODB_DATABASE", ""),
+		JWTSecret:                  getEnv("JWT_SECRET", ""),
+		AccessTokenTTL:             time.Duration(getEnvAsInt("ACCESS_TOKEN_MINUTES", 15)) * time.Minute,
+		RefreshTokenTTL:            time.Duration(getEnvAsInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour,
+		TrashRetention:             time.Duration(getEnvAsInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		SpamMinSubmitTime:          time.Duration(getEnvAsInt("SPAM_MIN_SUBMIT_SECONDS", 3)) * time.Second,
+		SpamScoreThreshold:         getEnvAsInt("SPAM_SCORE_THRESHOLD", 5),
+		SpamMaxLinks:               getEnvAsInt("SPAM_MAX_LINKS", 2),
+		SpamBlockedKeywords:        getEnvAsList("SPAM_BLOCKED_KEYWORDS"),
+		SpamDisposableDomains:      getEnvAsList("SPAM_DISPOSABLE_DOMAINS"),
+		RateLimitStore:             getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitContactIP:         getEnv("RATE_LIMIT_CONTACT_IP", "5/1m"),
+		RateLimitContactEmail:      getEnv("RATE_LIMIT_CONTACT_EMAIL", "3/1h"),
+		RateLimitVerificationIP:    getEnv("RATE_LIMIT_VERIFICATION_IP", "10/10m"),
+		RateLimitVerificationEmail: getEnv("RATE_LIMIT_VERIFICATION_EMAIL", "3/10m"),
+		TrustedProxies:             getEnvAsList("TRUSTED_PROXIES"),
+		TrustedPlatform:            getEnv("TRUSTED_PLATFORM", ""),
+		ConsentText:                getEnv("CONSENT_TEXT", "Sign me up for the Chanterelle mailing list. I can unsubscribe at any time."),
+		DoubleOptIn:                getEnvAsBool("DOUBLE_OPT_IN", false),
+		SubscriptionConfirmWindow:  time.Duration(getEnvAsInt("SUBSCRIPTION_CONFIRM_HOURS", 48)) * time.Hour,
+		FrontendURL:                getEnv("FRONTEND_URL", ""),
+		PrivacySelfService:         getEnvAsBool("PRIVACY_SELF_SERVICE", false),
+		AutoReplyEnabled:           getEnvAsBool("AUTO_REPLY_ENABLED", true),
+		AutoReplyWindow:            time.Duration(getEnvAsInt("AUTO_REPLY_SUPPRESS_HOURS", 24)) * time.Hour,
+		VerificationCodeLength:     6,
+		VerificationCodeExpiry:     15 * time.Minute,
+		VerificationMaxAttempts:    getEnvAsInt("VERIFICATION_MAX_ATTEMPTS", 5),
+		VerificationLockout:        time.Duration(getEnvAsInt("VERIFICATION_LOCKOUT_MINUTES", 5)) * time.Minute,
+		VerificationMaxLockout:     time.Duration(getEnvAsInt("VERIFICATION_MAX_LOCKOUT_HOURS", 24)) * time.Hour,
+		VerificationMaxIPFailures:  getEnvAsInt("VERIFICATION_MAX_IP_FAILURES", 0),
+		MailchimpAPIKey:            getEnv("MAILCHIMP_API_KEY", ""),
+		MailchimpListID:            getEnv("MAILCHIMP_LIST_ID", ""),
+		MailchimpSyncMaxAttempts:   getEnvAsInt("MAILCHIMP_SYNC_MAX_ATTEMPTS", 8),
+		AdminEmail:                 getEnv("ADMIN_EMAIL", ""),
+		EmailJSServiceID:           getEnv("EMAILJS_SERVICE_ID", ""),
+		EmailJSTemplateID:          getEnv("EMAILJS_TEMPLATE_ID", ""),
+		EmailJSUserID:              getEnv("EMAILJS_USER_ID", ""),
+		EmailJSAccessToken:         getEnv("EMAILJS_ACCESS_TOKEN", ""),
+		EmailJSReplyTemplateID:     getEnv("EMAILJS_REPLY_TEMPLATE_ID", ""),
+		EmailJSBookingTemplateID:   getEnv("EMAILJS_BOOKING_TEMPLATE_ID", ""),
+		EmailJSAutoReplyTemplateID: getEnv("EMAILJS_AUTO_REPLY_TEMPLATE_ID", ""),
+		EmailJSConfirmTemplateID:   getEnv("EMAILJS_CONFIRM_TEMPLATE_ID", ""),
 	}
 
 	// Validate required environment variables
@@ -82,6 +193,16 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	if config.VerificationMaxIPFailures > 0 && len(config.TrustedProxies) == 0 && config.TrustedPlatform == "" {
+		return nil, fmt.Errorf("VERIFICATION_MAX_IP_FAILURES needs TRUSTED_PROXIES or TRUSTED_PLATFORM to tell clients apart")
+	}
+	if config.DoubleOptIn && config.FrontendURL == "" {
+		return nil, fmt.Errorf("FRONTEND_URL must be set when DOUBLE_OPT_IN is enabled")
+	}
+	if config.PrivacySelfService && config.FrontendURL == "" {
+		return nil, fmt.Errorf("FRONTEND_URL must be set when PRIVACY_SELF_SERVICE is enabled")
+	}
+
 	return config, nil
 }
 
@@ -105,6 +226,25 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsList reads a comma-separated list, dropping empty entries.
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func NewDB(config *Config) (*sql.DB, error) {
 	// MongoDB doesn't use sql.DB, this function will be replaced with MongoDB client initialization
 	return nil, fmt.Errorf("MongoDB client initialization not implemented yet")
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+func (h *Handlers) GetAdmins(c *gin.Context) {
+	admins, err := h.adminService.GetAdmins(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+}
+
+// InviteAdmin gives someone access to the admin area and emails them.
+func (h *Handlers) InviteAdmin(c *gin.Context) {
+	var req struct {
+		Email string                 `json:"email" binding:"required,email"`
+		Role  repositories.AdminRole `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin, err := h.adminService.InviteAdmin(c.Request.Context(), req.Email, req.Role, c.GetString("email"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAdminRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrAdminExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case admin.Email != "":
+			// The admin was added but the invite wasn't sent; they can
+			// still sign in.
+			c.JSON(http.StatusCreated, gin.H{"admin": admin, "warning": "Failed to send invite email"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"admin": admin})
+}
+
+func (h *Handlers) RemoveAdmin(c *gin.Context) {
+	if err := h.adminService.RemoveAdmin(c.Request.Context(), c.Param("email")); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrAdminNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrProtectedAdmin):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Admin removed"})
+}
+
+// GetVerificationFailures lists failed sign-in attempts, newest first,
+// optionally for one email or IP.
+func (h *Handlers) GetVerificationFailures(c *gin.Context) {
+	filter := repositories.VerificationFailureFilter{
+		Email: c.Query("email"),
+		IP:    c.Query("ip"),
+		Limit: 100,
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	failures, err := h.verificationService.GetFailures(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"failures": failures})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handlers{}
+
+	tests := []struct {
+		name string
+		role interface{}
+		min  repositories.AdminRole
+		want int
+	}{
+		{"owner may edit", repositories.AdminRoleOwner, repositories.AdminRoleEditor, http.StatusOK},
+		{"editor may edit", repositories.AdminRoleEditor, repositories.AdminRoleEditor, http.StatusOK},
+		{"viewer may not edit", repositories.AdminRoleViewer, repositories.AdminRoleEditor, http.StatusForbidden},
+		{"editor may not manage admins", repositories.AdminRoleEditor, repositories.AdminRoleOwner, http.StatusForbidden},
+		{"unknown role", repositories.AdminRole("superuser"), repositories.AdminRoleViewer, http.StatusForbidden},
+		{"role as a plain string", "owner", repositories.AdminRoleViewer, http.StatusForbidden},
+		{"no role", nil, repositories.AdminRoleViewer, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				if tt.role != nil {
+					c.Set("role", tt.role)
+				}
+			}, h.RequireRole(tt.min), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
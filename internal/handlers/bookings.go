@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// CreateBooking accepts a booking inquiry from the public booking form.
+func (h *Handlers) CreateBooking(c *gin.Context) {
+	var inquiry models.BookingInquiry
+	if err := c.ShouldBindJSON(&inquiry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.bookingService.SubmitBooking(c.Request.Context(), inquiry, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBooking) || errors.Is(err, services.ErrPastEventDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Booking inquiry received", "id": booking.ID.Hex()})
+}
+
+// GetBookings lists booking inquiries. event_from and event_to bound the
+// event date and accept RFC 3339 times or YYYY-MM-DD dates; event_to is
+// inclusive of the whole day when given as a date. spam=true lists the
+// inquiries quarantined as spam.
+func (h *Handlers) GetBookings(c *gin.Context) {
+	filter := repositories.BookingFilter{EventType: c.Query("event_type"), Spam: c.Query("spam") == "true"}
+	for name, dest := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return
+		}
+		*dest = n
+	}
+
+	if value := c.Query("event_from"); value != "" {
+		t, _, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event_from"})
+			return
+		}
+		filter.EventFrom = &t
+	}
+	if value := c.Query("event_to"); value != "" {
+		t, dateOnly, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event_to"})
+			return
+		}
+		if dateOnly {
+			t = t.Add(24 * time.Hour)
+		}
+		filter.EventTo = &t
+	}
+
+	bookings, total, err := h.bookingService.GetBookings(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookings": bookings, "total": total})
+}
+
+func (h *Handlers) GetBookingByID(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	booking, err := h.bookingService.GetBookingByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"chanterelle/internal/config"
 	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
 	"chanterelle/internal/services"
 	"net/http"
 	"strings"
@@ -39,7 +40,12 @@ func (h *ContactHandler) CreateContact(c *gin.Context) {
 		return
 	}
 
-	err := h.contactService.CreateContact(c.Request.Context(), contact.Name, contact.Email, contact.Message)
+	err := h.contactService.CreateContact(c.Request.Context(), &repositories.Contact{
+		Name:    contact.Name,
+		Email:   contact.Email,
+		Message: contact.Message,
+		Source:  repositories.ContactSourceForm,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
 		return
@@ -60,10 +66,10 @@ func isValidEmail(email string) bool {
 }
 
 func (h *ContactHandler) GetContacts(c *gin.Context) {
-	contacts, err := h.contactService.GetContacts(c.Request.Context())
+	page, err := h.contactService.GetContacts(c.Request.Context(), repositories.ContactFilter{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"contacts": contacts})
+	c.JSON(http.StatusOK, gin.H{"contacts": page.Contacts})
 }
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// parseContactFilter reads the listing query parameters shared by the
+// contact endpoints:
+//
+//	limit, cursor        page size and the next_cursor of the previous page
+//	from, to             created_at range, RFC 3339 or YYYY-MM-DD (to is inclusive of the whole day)
+//	email, name          case-insensitive substring matches
+//	status               one of the contact workflow statuses
+//	category             one of the contact form categories
+//	mailchimp_status     pending, synced or failed
+//	tag                  repeatable; contacts must carry every tag given
+//	sort                 created_at or -created_at (default)
+func parseContactFilter(c *gin.Context) (repositories.ContactFilter, error) {
+	filter := repositories.ContactFilter{
+		Email:  c.Query("email"),
+		Name:   c.Query("name"),
+		Cursor: c.Query("cursor"),
+	}
+
+	if status := c.Query("status"); status != "" {
+		filter.Status = repositories.ContactStatus(status)
+		if !filter.Status.IsValid() {
+			return filter, fmt.Errorf("invalid status: %s", status)
+		}
+	}
+
+	if category := c.Query("category"); category != "" {
+		filter.Category = repositories.ContactCategory(category)
+		if !filter.Category.IsValid() {
+			return filter, fmt.Errorf("invalid category: %s", category)
+		}
+	}
+
+	if status := c.Query("mailchimp_status"); status != "" {
+		filter.MailchimpStatus = repositories.MailchimpSyncStatus(status)
+		if !filter.MailchimpStatus.IsValid() {
+			return filter, fmt.Errorf("invalid mailchimp_status: %s", status)
+		}
+	}
+
+	for _, tag := range c.QueryArray("tag") {
+		normalized, err := services.NormalizeTag(tag)
+		if err != nil {
+			return filter, err
+		}
+		filter.Tags = append(filter.Tags, normalized)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return filter, fmt.Errorf("invalid limit: %s", limit)
+		}
+		filter.Limit = n
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, _, err := parseQueryTime(from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date: %s", from)
+		}
+		filter.CreatedFrom = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, dateOnly, err := parseQueryTime(to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date: %s", to)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = &t
+	}
+
+	switch sort := c.DefaultQuery("sort", "-created_at"); sort {
+	case "-created_at":
+	case "created_at":
+		filter.Ascending = true
+	default:
+		return filter, fmt.Errorf("invalid sort: %s", sort)
+	}
+
+	return filter, nil
+}
+
+// parseQueryTime accepts either an RFC 3339 timestamp or a bare date. The
+// boolean reports whether the value was a bare date.
+func parseQueryTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
+
+// contactETag identifies the stored version of a contact.
+func contactETag(contact repositories.Contact) string {
+	return fmt.Sprintf(`"%d"`, contact.Version)
+}
+
+// parseContactETag reads the version back out of an If-Match value.
+func parseContactETag(value string) (int, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid If-Match value: %s", value)
+	}
+	return version, nil
+}
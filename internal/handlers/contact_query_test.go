@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newQueryContext returns a gin context for a GET request with the given
+// query string.
+func newQueryContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/contacts?"+query, nil)
+	return c
+}
+
+func TestParseContactFilter(t *testing.T) {
+	filter, err := parseContactFilter(newQueryContext("limit=25&email=Alex&from=2024-05-01&to=2024-05-31&sort=created_at&cursor=abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 25, filter.Limit)
+	assert.Equal(t, "Alex", filter.Email)
+	assert.Equal(t, "abc", filter.Cursor)
+	assert.True(t, filter.Ascending)
+	require.NotNil(t, filter.CreatedFrom)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedFrom)
+	// A bare to date includes the whole day.
+	require.NotNil(t, filter.CreatedTo)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedTo)
+
+	filter, err = parseContactFilter(newQueryContext("to=2024-05-31T10:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC), *filter.CreatedTo)
+	assert.False(t, filter.Ascending)
+	assert.Zero(t, filter.Limit)
+}
+
+func TestParseContactFilterRejectsInvalidValues(t *testing.T) {
+	queries := []string{
+		"limit=0",
+		"limit=-1",
+		"limit=ten",
+		"from=May",
+		"to=2024-13-01",
+		"sort=name",
+	}
+	for _, query := range queries {
+		_, err := parseContactFilter(newQueryContext(query))
+		assert.Error(t, err, query)
+	}
+}
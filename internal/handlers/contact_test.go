@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryContactRepository is an in-memory ContactRepository for tests.
+type memoryContactRepository struct {
+	repositories.ContactRepository
+	contacts map[string]*repositories.Contact
+}
+
+func newMemoryContactRepository(contacts ...repositories.Contact) *memoryContactRepository {
+	r := &memoryContactRepository{contacts: map[string]*repositories.Contact{}}
+	for i := range contacts {
+		r.contacts[contacts[i].ID.Hex()] = &contacts[i]
+	}
+	return r
+}
+
+func (r *memoryContactRepository) GetContactByID(_ context.Context, id string) (repositories.Contact, error) {
+	contact, ok := r.contacts[id]
+	if !ok {
+		return repositories.Contact{}, repositories.ErrContactNotFound
+	}
+	return *contact, nil
+}
+
+func (r *memoryContactRepository) UpdateContact(_ context.Context, id string, update repositories.ContactUpdate) (repositories.Contact, error) {
+	contact, ok := r.contacts[id]
+	if !ok {
+		return repositories.Contact{}, repositories.ErrContactNotFound
+	}
+	if contact.Version != update.ExpectedVersion {
+		return repositories.Contact{}, repositories.ErrVersionConflict
+	}
+	if update.Name != nil {
+		contact.Name = *update.Name
+	}
+	if update.Email != nil {
+		contact.Email = *update.Email
+	}
+	if update.Message != nil {
+		contact.Message = *update.Message
+	}
+	contact.Version++
+	return *contact, nil
+}
+
+// patchContact sends a PATCH for the contact through UpdateContact.
+func patchContact(h *Handlers, id, ifMatch, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodPatch, "/contacts/"+id, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		c.Request.Header.Set("If-Match", ifMatch)
+	}
+	h.UpdateContact(c)
+	return w
+}
+
+func TestUpdateContactPreconditions(t *testing.T) {
+	contact := repositories.Contact{
+		ID:      primitive.NewObjectID(),
+		Name:    "Alex",
+		Email:   "alex@example.com",
+		Version: 3,
+	}
+	repo := newMemoryContactRepository(contact)
+	h := &Handlers{contactService: services.NewContactService(repo, nil)}
+	id := contact.ID.Hex()
+
+	w := patchContact(h, id, "", `{"name":"Alexandra"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	w = patchContact(h, id, `"2"`, `{"name":"Alexandra"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = patchContact(h, id, "yesterday", `{"name":"Alexandra"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = patchContact(h, primitive.NewObjectID().Hex(), `"3"`, `{"name":"Alexandra"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	stored, err := repo.GetContactByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alex", stored.Name, "rejected updates must not be applied")
+
+	w = patchContact(h, id, `W/"3"`, `{"name":"Alexandra"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+	var updated repositories.Contact
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Alexandra", updated.Name)
+	assert.Equal(t, "alex@example.com", updated.Email, "fields left out of the body are unchanged")
+
+	// The old ETag is now stale.
+	w = patchContact(h, id, `"3"`, `{"message":"Hi"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
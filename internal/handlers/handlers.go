@@ -1,33 +1,86 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"chanterelle/internal/config"
-	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
 	"chanterelle/internal/services"
 )
 
+// maxImportSize caps the size of an uploaded contact import.
+const maxImportSize = 5 << 20
+
 type Handlers struct {
 	contactService      *services.ContactService
 	notificationService *services.NotificationService
 	verificationService *services.VerificationService
+	importService       *services.ImportService
+	noteService         *services.NoteService
+	bookingService      *services.BookingService
+	routingService      *services.RoutingService
+	autoReplyService    *services.AutoReplyService
+	mailchimpSync       *services.MailchimpSyncService
+	subscriptionService *services.SubscriptionService
+	consentService      *services.ConsentService
+	privacyService      *services.PrivacyService
+	adminService        *services.AdminService
+	sessionService      *services.SessionService
+	totpService         *services.TOTPService
+	replyService        *services.ReplyService
+	spamService         *services.SpamService
 	config              *config.Config
 }
 
-func NewHandlers(contactService *services.ContactService, notificationService *services.NotificationService, verificationService *services.VerificationService, config *config.Config) *Handlers {
+// Services are the services the handlers call.
+type Services struct {
+	Contacts      *services.ContactService
+	Notifications *services.NotificationService
+	Verification  *services.VerificationService
+	Notes         *services.NoteService
+	Bookings      *services.BookingService
+	Routing       *services.RoutingService
+	Spam          *services.SpamService
+	AutoReplies   *services.AutoReplyService
+	MailchimpSync *services.MailchimpSyncService
+	Subscriptions *services.SubscriptionService
+	Consents      *services.ConsentService
+	Privacy       *services.PrivacyService
+	Admins        *services.AdminService
+	Sessions      *services.SessionService
+	TOTP          *services.TOTPService
+}
+
+func NewHandlers(svc Services, config *config.Config) *Handlers {
 	return &Handlers{
-		contactService:      contactService,
-		notificationService: notificationService,
-		verificationService: verificationService,
+		contactService:      svc.Contacts,
+		notificationService: svc.Notifications,
+		verificationService: svc.Verification,
+		noteService:         svc.Notes,
+		bookingService:      svc.Bookings,
+		routingService:      svc.Routing,
+		autoReplyService:    svc.AutoReplies,
+		mailchimpSync:       svc.MailchimpSync,
+		subscriptionService: svc.Subscriptions,
+		consentService:      svc.Consents,
+		privacyService:      svc.Privacy,
+		adminService:        svc.Admins,
+		sessionService:      svc.Sessions,
+		totpService:         svc.TOTP,
+		importService:       services.NewImportService(svc.Contacts, svc.MailchimpSync, svc.Consents),
+		replyService:        services.NewReplyService(svc.Contacts, svc.Notifications),
+		spamService:         svc.Spam,
 		config:              config,
 	}
 }
@@ -37,6 +90,15 @@ func (h *Handlers) CreateContact(c *gin.Context) {
 		Name    string `json:"name" binding:"required"`
 		Email   string `json:"email" binding:"required,email"`
 		Message string `json:"message"`
+		// Category is what the message is about; general when empty.
+		Category repositories.ContactCategory `json:"category"`
+		// Website is a honeypot field hidden from real visitors.
+		Website   string `json:"website"`
+		FormToken string `json:"form_token"`
+		// Subscribe is set when the sender ticked the mailing list box,
+		// whose wording is identified by ConsentVersion.
+		Subscribe      bool   `json:"subscribe"`
+		ConsentVersion string `json:"consent_version"`
 	}
 
 	if err := c.ShouldBindJSON(&contact); err != nil {
@@ -44,30 +106,217 @@ func (h *Handlers) CreateContact(c *gin.Context) {
 		return
 	}
 
-	if err := h.contactService.CreateContact(c.Request.Context(), contact.Name, contact.Email, contact.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var consent *services.SignupConsent
+	if contact.Subscribe {
+		given, err := h.consentService.SignupConsent(contact.ConsentVersion, services.ConsentEvidence{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		consent = &given
+	}
+
+	if contact.Category == "" {
+		contact.Category = repositories.ContactCategoryGeneral
+	}
+	if !contact.Category.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
 		return
 	}
 
-	if err := h.notificationService.AddToMailchimp(&models.Contact{
-		Name:  contact.Name,
-		Email: contact.Email,
-	}); err != nil {
+	verdict := h.spamService.Evaluate(c.Request.Context(), services.Submission{
+		Name:       contact.Name,
+		Email:      contact.Email,
+		Message:    contact.Message,
+		Honeypot:   contact.Website,
+		FormToken:  contact.FormToken,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		ReceivedAt: time.Now(),
+	})
+
+	stored := &repositories.Contact{
+		Name:     contact.Name,
+		Email:    contact.Email,
+		Message:  contact.Message,
+		Source:   repositories.ContactSourceForm,
+		Category: contact.Category,
+	}
+	if verdict.Spam {
+		// Suspected spam is kept for review in the spam folder but never
+		// subscribed. The sender gets the usual response so bots learn
+		// nothing from it.
+		stored.Status = repositories.ContactStatusSpam
+		stored.SpamScore = verdict.Score
+		stored.SpamReasons = verdict.Reasons
+	}
+
+	if err := h.contactService.CreateContact(c.Request.Context(), stored); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if verdict.Spam {
+		log.Printf("Quarantined contact %s as spam (score %d): %s", stored.ID.Hex(), verdict.Score, strings.Join(verdict.Reasons, "; "))
+		c.JSON(http.StatusCreated, gin.H{"message": "Contact created successfully"})
+		return
+	}
+
+	if _, err := h.autoReplyService.Acknowledge(c.Request.Context(), stored); err != nil {
+		log.Printf("Failed to send auto-reply for contact %s: %v", stored.ID.Hex(), err)
+	}
+
+	h.routingService.RouteContact(c.Request.Context(), stored, consent)
+
 	c.JSON(http.StatusCreated, gin.H{"message": "Contact created successfully"})
 }
 
+// GetContactFormToken issues the signed token the contact form submits back
+// so the time taken to fill it in can be checked, along with the mailing
+// list consent text the form shows and its version.
+func (h *Handlers) GetContactFormToken(c *gin.Context) {
+	token, err := h.spamService.IssueFormToken(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"token":           token,
+		"consent_text":    h.consentService.ConsentText(),
+		"consent_version": h.consentService.ConsentVersion(),
+	})
+}
+
 func (h *Handlers) GetContacts(c *gin.Context) {
-	contacts, err := h.contactService.GetContacts(c.Request.Context())
+	filter, err := parseContactFilter(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.contactService.GetContacts(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, contacts)
+	c.JSON(http.StatusOK, gin.H{
+		"contacts":    page.Contacts,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+func (h *Handlers) SearchContacts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	hits, err := h.contactService.SearchContacts(c.Request.Context(), query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": hits})
+}
+
+// ExportContacts streams the contacts matching the listing filters as a
+// file download. The format query parameter selects csv (default), ndjson
+// or vcard.
+func (h *Handlers) ExportContacts(c *gin.Context) {
+	filter, err := parseContactFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := services.ExportFormat(c.DefaultQuery("format", string(services.ExportFormatCSV)))
+	switch format {
+	case services.ExportFormatCSV, services.ExportFormatNDJSON, services.ExportFormatVCard:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export format"})
+		return
+	}
+
+	filename := fmt.Sprintf("contacts-%s.%s", time.Now().Format("2006-01-02"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	if err := h.contactService.ExportContacts(c.Request.Context(), filter, format, c.Writer); err != nil {
+		// The response has already started, so the error can only be logged.
+		log.Printf("Failed to export contacts: %v", err)
+		c.Abort()
+	}
+}
+
+// ImportContacts bulk-imports contacts from CSV, sent either as the "file"
+// field of a multipart form or as the raw request body. dry_run=true reports
+// what would happen without writing; subscribe=true also adds created
+// contacts to Mailchimp.
+func (h *Handlers) ImportContacts(c *gin.Context) {
+	var opts services.ImportOptions
+	var err error
+	if opts.DryRun, err = strconv.ParseBool(c.DefaultQuery("dry_run", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run value"})
+		return
+	}
+	if opts.Subscribe, err = strconv.ParseBool(c.DefaultQuery("subscribe", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscribe value"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	report, err := h.importService.ImportCSV(c.Request.Context(), body, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImportFile) || errors.Is(err, services.ErrMissingEmailColumn) ||
+			errors.Is(err, services.ErrMissingConsentColumn) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
 }
 
 func (h *Handlers) GetContactByID(c *gin.Context) {
@@ -79,13 +328,21 @@ func (h *Handlers) GetContactByID(c *gin.Context) {
 
 	contact, err := h.contactService.GetContactByID(c.Request.Context(), id)
 	if err != nil {
+		if errors.Is(err, repositories.ErrContactNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", contactETag(contact))
 	c.JSON(http.StatusOK, contact)
 }
 
+// UpdateContact applies a partial update. Only the fields present in the
+// body are changed, and the request must carry the contact's current ETag in
+// If-Match so concurrent edits are not silently overwritten.
 func (h *Handlers) UpdateContact(c *gin.Context) {
 	id := c.Param("id")
 	if _, err := primitive.ObjectIDFromHex(id); err != nil {
@@ -93,23 +350,53 @@ func (h *Handlers) UpdateContact(c *gin.Context) {
 		return
 	}
 
-	var contact struct {
-		Name    string `json:"name"`
-		Email   string `json:"email"`
-		Message string `json:"message"`
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return
+	}
+	version, err := parseContactETag(ifMatch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&contact); err != nil {
+	var req struct {
+		Name    *string `json:"name" binding:"omitnil,min=2,max=100"`
+		Email   *string `json:"email" binding:"omitnil,email"`
+		Message *string `json:"message" binding:"omitnil,max=500"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.contactService.UpdateContact(c.Request.Context(), id, contact.Name, contact.Email, contact.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if req.Name == nil && req.Email == nil && req.Message == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Contact updated successfully"})
+	contact, err := h.contactService.UpdateContact(c.Request.Context(), id, repositories.ContactUpdate{
+		Name:            req.Name,
+		Email:           req.Email,
+		Message:         req.Message,
+		ExpectedVersion: version,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrContactNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", contactETag(contact))
+	c.JSON(http.StatusOK, contact)
 }
 
 func (h *Handlers) DeleteContact(c *gin.Context) {
@@ -119,12 +406,110 @@ func (h *Handlers) DeleteContact(c *gin.Context) {
 		return
 	}
 
-	if err := h.contactService.DeleteContact(c.Request.Context(), id); err != nil {
+	if err := h.contactService.DeleteContact(c.Request.Context(), id, c.GetString("email")); err != nil {
+		if errors.Is(err, repositories.ErrContactNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact moved to trash"})
+}
+
+// GetTrash lists contacts in the trash using the listing query parameters.
+func (h *Handlers) GetTrash(c *gin.Context) {
+	filter, err := parseContactFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Trashed = true
+
+	page, err := h.contactService.GetContacts(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contacts":    page.Contacts,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+func (h *Handlers) RestoreContact(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	contact, err := h.contactService.RestoreContact(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrContactNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrContactNotTrashed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *Handlers) ChangeContactStatus(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		Status repositories.ContactStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.contactService.ChangeContactStatus(c.Request.Context(), id, req.Status, c.GetString("email"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownContactStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrContactNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidStatusTransition):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrStatusChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *Handlers) GetContactStatusCounts(c *gin.Context) {
+	counts, err := h.contactService.CountContactsByStatus(c.Request.Context())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"counts": counts})
 }
 
 func (h *Handlers) JWTAuth() gin.HandlerFunc {
@@ -145,34 +530,34 @@ func (h *Handlers) JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the alg is what you expect
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(h.config.JWTSecret), nil
-		})
-
+		// Validate the token and that its session hasn't been revoked
+		claims, err := h.sessionService.Authenticate(c.Request.Context(), parts[1], c.ClientIP())
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			if errors.Is(err, services.ErrInvalidAccessToken) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Add the email from the token to the context
-			if email, ok := claims["email"].(string); ok {
-				c.Set("email", email)
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-				c.Abort()
-				return
-			}
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		// Add the admin and session from the token to the context
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+
+		c.Next()
+	}
+}
+
+// RequireRole only lets through admins whose role grants at least min. It
+// must run after JWTAuth.
+func (h *Handlers) RequireRole(min repositories.AdminRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		if adminRole, ok := role.(repositories.AdminRole); !ok || !adminRole.Allows(min) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("This requires the %s role", min)})
 			c.Abort()
 			return
 		}
@@ -192,25 +577,29 @@ func (h *Handlers) SendVerification(c *gin.Context) {
 		return
 	}
 
-	// Only generate code for admin email
-	if req.Email != h.config.AdminEmail {
+	// Only generate codes for admins who sign in with emailed codes
+	admin, err := h.adminService.GetAdmin(c.Request.Context(), req.Email)
+	if errors.Is(err, repositories.ErrAdminNotFound) || (err == nil && !admin.LoginPolicy.AllowsEmail()) {
 		// Return success regardless of email
 		c.JSON(http.StatusOK, gin.H{
 			"message": "If the email was valid, you'll receive a verification code",
 		})
 		return
 	}
-
-	code, err := h.verificationService.CreateVerificationCode(c.Request.Context(), req.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Store the code in the session for verification
-	c.SetCookie("verification_code", code, 300, "/", "", false, true)
+	code, err := h.verificationService.CreateVerificationCode(c.Request.Context(), admin.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	h.notificationService.SendVerificationCode(req.Email, code)
+	if err := h.notificationService.SendVerificationCode(admin.Email, code); err != nil {
+		log.Printf("Failed to send verification code: %v", err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "If the email was valid, you'll receive a verification code",
@@ -220,7 +609,11 @@ func (h *Handlers) SendVerification(c *gin.Context) {
 func (h *Handlers) VerifyCode(c *gin.Context) {
 	var req struct {
 		Email string `json:"email" binding:"required,email"`
-		Code  string `json:"code" binding:"required,len=6"`
+		Code  string `json:"code" binding:"required,max=20"`
+		// Method is "email" for an emailed code or "totp" for an
+		// authenticator or recovery code. It defaults to what the admin's
+		// login policy prefers.
+		Method string `json:"method"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -228,47 +621,64 @@ func (h *Handlers) VerifyCode(c *gin.Context) {
 		return
 	}
 
-	// Only accept verification for admin email
-	if req.Email != h.config.AdminEmail {
+	// Only accept verification for admins. Other addresses never get a
+	// code, so they aren't counted as failures.
+	admin, err := h.adminService.GetAdmin(c.Request.Context(), req.Email)
+	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid email"})
 		return
 	}
 
-	// Verify the code using the repository
-	verificationCode, err := h.verificationService.GetCodeByEmail(c.Request.Context(), req.Email)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired verification code"})
-		return
+	method := req.Method
+	if method == "" {
+		method = "email"
+		if admin.LoginPolicy == repositories.LoginPolicyTOTP {
+			method = "totp"
+		}
 	}
-
-	if verificationCode.Code != req.Code {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid verification code"})
+	switch {
+	case method == "email" && admin.LoginPolicy.AllowsEmail():
+		// Verify and consume the code, counting wrong guesses
+		err = h.verificationService.VerifyCode(c.Request.Context(), admin.Email, req.Code, c.ClientIP())
+	case method == "totp" && admin.LoginPolicy.AllowsTOTP():
+		err = h.verificationService.VerifyAuthenticatorCode(c.Request.Context(), admin.Email, c.ClientIP(), func(ctx context.Context) error {
+			return h.totpService.Verify(ctx, admin, req.Code)
+		})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "This sign-in method isn't allowed for this account"})
 		return
 	}
-
-	// Delete the verification code after successful verification
-	if err := h.verificationService.DeleteCodeByEmail(c.Request.Context(), req.Email); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete verification"})
+	if err != nil {
+		var locked *services.VerificationLockedError
+		switch {
+		case errors.As(err, &locked):
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrVerificationCodeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidVerificationCode):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid verification code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete verification"})
+		}
 		return
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": req.Email,
-		"exp":   time.Now().Add(24 * time.Hour).Unix(), // Token expires in 24 hours
-	})
-
-	tokenString, err := token.SignedString([]byte(h.config.JWTSecret))
+	// Start a session for this device
+	tokens, err := h.sessionService.StartSession(c.Request.Context(), admin, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	// Set the verified email header for subsequent requests
-	c.Writer.Header().Set("X-Verified-Email", req.Email)
+	c.Writer.Header().Set("X-Verified-Email", admin.Email)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Verification successful",
-		"token":   tokenString,
+		"message":       "Verification successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"role":          admin.Role,
 	})
 }
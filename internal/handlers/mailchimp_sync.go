@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+)
+
+// GetMailchimpSyncCounts reports how many contacts are waiting for, have
+// finished or have given up on their Mailchimp sync. List the contacts in
+// one state with GET /contacts?mailchimp_status=failed.
+func (h *Handlers) GetMailchimpSyncCounts(c *gin.Context) {
+	counts, err := h.mailchimpSync.CountSyncs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"counts": counts})
+}
+
+// RetryMailchimpSync queues a contact's Mailchimp sync again.
+func (h *Handlers) RetryMailchimpSync(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	contact, err := h.mailchimpSync.Retry(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrContactNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, contact)
+}
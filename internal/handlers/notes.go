@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+func (h *Handlers) GetContactNotes(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	threads, err := h.noteService.GetNoteThreads(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrContactNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notes": threads})
+}
+
+// AddContactNote adds an internal note to a contact, or a reply to an
+// existing note when parent_id is given.
+func (h *Handlers) AddContactNote(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		Body     string `json:"body" binding:"required,max=5000"`
+		ParentID string `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.noteService.AddNote(c.Request.Context(), id, req.ParentID, c.GetString("email"), req.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptyNote), errors.Is(err, services.ErrInvalidParent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrContactNotFound), errors.Is(err, repositories.ErrNoteNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+func (h *Handlers) GetPeople(c *gin.Context) {
+	filter := repositories.PersonFilter{Query: c.Query("q")}
+	for name, dest := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return
+		}
+		*dest = n
+	}
+
+	people, total, err := h.contactService.GetPeople(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"people": people, "total": total})
+}
+
+// ExportPersonConsents downloads the mailing list consent records of a
+// person as JSON.
+func (h *Handlers) ExportPersonConsents(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	consents, err := h.consentService.GetPersonConsents(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPersonNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="consents-%s.json"`, id))
+	c.IndentedJSON(http.StatusOK, gin.H{"person_id": id, "consents": consents})
+}
+
+func (h *Handlers) GetPerson(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	person, err := h.contactService.GetPerson(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPersonNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+// GetPersonTimeline lists a person's submissions using the same query
+// parameters as the contact listing.
+func (h *Handlers) GetPersonTimeline(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	filter, err := parseContactFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.contactService.GetPersonTimeline(c.Request.Context(), id, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrPersonNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contacts":    page.Contacts,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// MergePeople merges the person given in the body into the person in the
+// path.
+func (h *Handlers) MergePeople(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	var req struct {
+		PersonID string `json:"person_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := primitive.ObjectIDFromHex(req.PersonID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid person ID"})
+		return
+	}
+
+	person, err := h.contactService.MergePeople(c.Request.Context(), id, req.PersonID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMergeSamePerson):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrPersonNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/services"
+)
+
+// ExportPersonalData downloads everything stored about an email address as
+// JSON, for answering a data subject access request.
+func (h *Handlers) ExportPersonalData(c *gin.Context) {
+	email := strings.TrimSpace(c.Query("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	h.sendPrivacyArchive(c, email)
+}
+
+// ErasePersonalData deletes everything stored about an email address.
+func (h *Handlers) ErasePersonalData(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.privacyService.Erase(c.Request.Context(), req.Email, c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RequestPrivacyLink emails the owner of an address a link to export or
+// erase their data. It answers the same way whether or not any data exists.
+func (h *Handlers) RequestPrivacyLink(c *gin.Context) {
+	var req struct {
+		Email  string `json:"email" binding:"required,email"`
+		Action string `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.privacyService.RequestSelfService(c.Request.Context(), req.Email, req.Action); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPrivacySelfService):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrInvalidPrivacyAction):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to send privacy request link: %v", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If we hold data for that address, a link has been emailed to it"})
+}
+
+// ExportOwnData downloads a person's data using the link emailed to them.
+func (h *Handlers) ExportOwnData(c *gin.Context) {
+	email, ok := h.verifyPrivacyToken(c, services.PrivacyActionExport)
+	if !ok {
+		return
+	}
+
+	h.sendPrivacyArchive(c, email)
+}
+
+// EraseOwnData erases a person's data using the link emailed to them.
+func (h *Handlers) EraseOwnData(c *gin.Context) {
+	email, ok := h.verifyPrivacyToken(c, services.PrivacyActionErase)
+	if !ok {
+		return
+	}
+
+	report, err := h.privacyService.Erase(c.Request.Context(), email, "self-service")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your data has been erased", "mailchimp_archived": report.MailchimpArchived})
+}
+
+// verifyPrivacyToken reads the token from the request body and writes the
+// error response when it isn't valid for action.
+func (h *Handlers) verifyPrivacyToken(c *gin.Context, action string) (string, bool) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	email, err := h.privacyService.VerifySelfService(req.Token, action)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPrivacySelfService):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return "", false
+	}
+	return email, true
+}
+
+func (h *Handlers) sendPrivacyArchive(c *gin.Context, email string) {
+	archive, err := h.privacyService.Export(c.Request.Context(), email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="personal-data-%s.json"`, archive.GeneratedAt.Format("20060102-150405")))
+	c.IndentedJSON(http.StatusOK, archive)
+}
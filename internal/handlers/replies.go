@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// ReplyToContact emails a reply to the contact and records it on the
+// contact's thread.
+func (h *Handlers) ReplyToContact(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		Subject string `json:"subject" binding:"max=200"`
+		Message string `json:"message" binding:"required,max=5000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.replyService.Reply(c.Request.Context(), id, req.Subject, req.Message, c.GetString("email"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptyReply):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrContactNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrReplyNotSent):
+			log.Printf("Failed to send reply to contact %s: %v", id, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send reply"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", contactETag(contact))
+	c.JSON(http.StatusOK, contact)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// GetRoutingRules lists the rule in effect for every contact category,
+// including defaults for categories nobody has configured.
+func (h *Handlers) GetRoutingRules(c *gin.Context) {
+	rules, err := h.routingService.GetRoutingRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// UpdateRoutingRule replaces the routing rule for a category.
+func (h *Handlers) UpdateRoutingRule(c *gin.Context) {
+	var req struct {
+		NotifyEmails        []string `json:"notify_emails" binding:"max=20,dive,email"`
+		Subscribe           bool     `json:"subscribe"`
+		AutoReplyTemplateID string   `json:"auto_reply_template_id" binding:"max=100"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.routingService.SaveRoutingRule(c.Request.Context(), repositories.RoutingRule{
+		Category:            repositories.ContactCategory(c.Param("category")),
+		NotifyEmails:        req.NotifyEmails,
+		Subscribe:           req.Subscribe,
+		AutoReplyTemplateID: req.AutoReplyTemplateID,
+	}, c.GetString("email"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownContactCategory) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// RefreshSession exchanges a refresh token for a new access token and
+// refresh token. Each refresh token works once; replaying one ends the
+// session.
+func (h *Handlers) RefreshSession(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(c.Request.Context(), req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout ends the session of the token used to call it.
+func (h *Handlers) Logout(c *gin.Context) {
+	sessionID, _ := c.Get("session_id")
+	h.revokeSession(c, sessionID.(primitive.ObjectID))
+}
+
+// LogoutAll signs the admin out on every device.
+func (h *Handlers) LogoutAll(c *gin.Context) {
+	revoked, err := h.sessionService.LogoutAll(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out on all devices", "revoked": revoked})
+}
+
+// GetSessions lists the devices the admin is signed in on.
+func (h *Handlers) GetSessions(c *gin.Context) {
+	sessions, err := h.sessionService.GetSessions(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, _ := c.Get("session_id")
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "current": current})
+}
+
+// RevokeSession signs the admin out on one of their devices.
+func (h *Handlers) RevokeSession(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	h.revokeSession(c, id)
+}
+
+func (h *Handlers) revokeSession(c *gin.Context, id primitive.ObjectID) {
+	if err := h.sessionService.Logout(c.Request.Context(), c.GetString("email"), id); err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
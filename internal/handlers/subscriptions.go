@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// ConfirmSubscription confirms a double opt-in signup with the token from
+// the confirmation email. The frontend page the link opens posts the token
+// here, so link scanners that only fetch the page don't confirm anything.
+func (h *Handlers) ConfirmSubscription(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.subscriptionService.Confirm(c.Request.Context(), req.Token, services.ConsentEvidence{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSubscriptionToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrSubscriptionExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription confirmed", "email": subscription.Email})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+func (h *Handlers) AddContactTags(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req struct {
+		Tags []string `json:"tags" binding:"required,min=1,max=20"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.contactService.AddContactTags(c.Request.Context(), id, req.Tags)
+	h.respondWithTaggedContact(c, contact, err)
+}
+
+func (h *Handlers) RemoveContactTag(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	contact, err := h.contactService.RemoveContactTag(c.Request.Context(), id, c.Param("tag"))
+	h.respondWithTaggedContact(c, contact, err)
+}
+
+func (h *Handlers) respondWithTaggedContact(c *gin.Context, contact repositories.Contact, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTag):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrContactNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", contactETag(contact))
+	c.JSON(http.StatusOK, contact)
+}
+
+// GetTags autocompletes tags. prefix narrows the suggestions and limit caps
+// how many are returned.
+func (h *Handlers) GetTags(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.contactService.SuggestTags(c.Request.Context(), c.Query("prefix"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
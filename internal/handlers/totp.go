@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"chanterelle/internal/repositories"
+	"chanterelle/internal/services"
+)
+
+// EnrollTOTP starts setting up an authenticator app for the signed-in
+// admin and returns the secret and its otpauth:// URI for a QR code.
+func (h *Handlers) EnrollTOTP(c *gin.Context) {
+	enrollment, err := h.totpService.BeginEnrollment(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP finishes enrollment with a code from the app and returns the
+// recovery codes, which are never shown again. Replacing an authenticator
+// also needs current_code, from the old one or a recovery code.
+func (h *Handlers) ConfirmTOTP(c *gin.Context) {
+	var req struct {
+		Code        string `json:"code" binding:"required"`
+		CurrentCode string `json:"current_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.totpService.ConfirmEnrollment(c.Request.Context(), c.GetString("email"), req.Code, req.CurrentCode, c.ClientIP())
+	if err != nil {
+		if respondCurrentCodeError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidTOTPCode), errors.Is(err, services.ErrTOTPEnrollmentEmpty):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrTOTPEnrollmentChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Authenticator enabled", "recovery_codes": codes})
+}
+
+// DisableTOTP removes the signed-in admin's authenticator. It needs a
+// current authenticator or recovery code.
+func (h *Handlers) DisableTOTP(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.totpService.Disable(c.Request.Context(), c.GetString("email"), req.Code, c.ClientIP()); err != nil {
+		if !respondCurrentCodeError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Authenticator removed"})
+}
+
+// UpdateLoginPolicy chooses whether the signed-in admin signs in with
+// emailed codes, authenticator codes or either. Once an authenticator is
+// on, code must be a current authenticator or recovery code.
+func (h *Handlers) UpdateLoginPolicy(c *gin.Context) {
+	var req struct {
+		Policy repositories.LoginPolicy `json:"policy" binding:"required"`
+		Code   string                   `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.totpService.SetPolicy(c.Request.Context(), c.GetString("email"), req.Policy, req.Code, c.ClientIP()); err != nil {
+		if respondCurrentCodeError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidLoginPolicy), errors.Is(err, services.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": req.Policy})
+}
+
+// respondCurrentCodeError answers a missing, wrong or locked out current
+// authenticator code the way signing in does, and reports whether err was
+// one.
+func respondCurrentCodeError(c *gin.Context, err error) bool {
+	var locked *services.VerificationLockedError
+	switch {
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTOTPCodeRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidVerificationCode):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid verification code"})
+	default:
+		return false
+	}
+	return true
+}
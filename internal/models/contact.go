@@ -16,3 +16,22 @@ type VerificationCode struct {
 	Code      string    `json:"code"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
+
+// BookingInquiry is a request to book the band, submitted through the
+// booking form. EventDate is a calendar date (YYYY-MM-DD) in the venue's
+// local time.
+type BookingInquiry struct {
+	Name      string `json:"name" validate:"required,min=2,max=100"`
+	Email     string `json:"email" validate:"required,email"`
+	Phone     string `json:"phone" validate:"omitempty,max=30"`
+	EventType string `json:"event_type" validate:"required,oneof=wedding private corporate festival club other"`
+	EventDate string `json:"event_date" validate:"required,datetime=2006-01-02"`
+	Venue     string `json:"venue" validate:"required,max=200"`
+	City      string `json:"city" validate:"required,max=100"`
+	Capacity  int    `json:"capacity" validate:"omitempty,min=1,max=100000"`
+	Budget    string `json:"budget" validate:"omitempty,max=100"`
+	Message   string `json:"message" validate:"max=2000"`
+	// Website is a honeypot field hidden from real visitors.
+	Website   string `json:"website"`
+	FormToken string `json:"form_token"`
+}
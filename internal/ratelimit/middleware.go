@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize caps the body of a rate limited request. Bodies are read
+// before the handler runs, so the cap applies before anything is trusted.
+const MaxBodySize = 64 << 10
+
+// Rule limits one route. Zero limits are not enforced.
+type Rule struct {
+	// Name namespaces the route's buckets so routes don't share limits.
+	Name string
+	// PerIP limits requests from each client IP.
+	PerIP Limit
+	// PerEmail limits requests submitting each email address, read from the
+	// "email" field of a JSON body whatever its Content-Type, as handlers
+	// bind JSON regardless. Requests without one are only limited by IP.
+	PerEmail Limit
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Middleware enforces rule, answering 429 Too Many Requests with a
+// Retry-After header once a bucket is empty. If the store fails the request
+// is let through, so an outage of the shared store doesn't take the form
+// down with it.
+func (l *Limiter) Middleware(rule Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := l.now()
+
+		if rule.PerIP.Enabled() {
+			if !l.take(c, rule.Name+":ip:"+c.ClientIP(), rule.PerIP, now) {
+				return
+			}
+		}
+
+		if rule.PerEmail.Enabled() {
+			email, err := submittedEmail(c)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+				return
+			}
+			if email != "" {
+				if !l.take(c, rule.Name+":email:"+email, rule.PerEmail, now) {
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// take reports whether the request may continue, aborting it if not.
+func (l *Limiter) take(c *gin.Context, key string, limit Limit, now time.Time) bool {
+	result, err := l.store.Take(c.Request.Context(), key, limit, now)
+	if err != nil {
+		log.Printf("Rate limit store failed for %s: %v", key, err)
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many requests, please try again later",
+		"retry_after": seconds,
+	})
+	return false
+}
+
+// submittedEmail reads the email field of a JSON body, leaving the body in
+// place for the handler. It fails only when the body is over MaxBodySize.
+func submittedEmail(c *gin.Context) (string, error) {
+	if c.Request.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodySize))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return "", err
+	}
+	if err != nil {
+		return "", nil
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", nil
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email)), nil
+}
@@ -0,0 +1,88 @@
+// Package ratelimit throttles requests with token buckets keyed by client IP
+// or by the email address a request submits.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit is a token bucket that holds up to Burst requests and refills at
+// Burst per Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit reads a limit written as "N/duration", for example "5/1m" for
+// five requests a minute. An empty string is the zero Limit, which disables
+// limiting.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %q is not N/duration", ErrInvalidLimit, value)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("%w: %q needs a positive request count", ErrInvalidLimit, value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q needs a positive duration", ErrInvalidLimit, value)
+	}
+	return Limit{Burst: burst, Period: d}, nil
+}
+
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+// rate is the number of tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long until the next token is available when the
+	// request was not allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Implementations must take tokens atomically so
+// concurrent requests for the same key can't overspend.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// refill returns the tokens in a bucket after it has been refilling since
+// updatedAt.
+func refill(tokens float64, updatedAt, now time.Time, limit Limit) float64 {
+	if elapsed := now.Sub(updatedAt); elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.rate()
+	}
+	if full := float64(limit.Burst); tokens > full {
+		tokens = full
+	}
+	return tokens
+}
+
+// retryAfter is how long a bucket holding tokens takes to reach one token.
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	return time.Duration((1 - tokens) / limit.rate() * float64(time.Second))
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("5/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Burst: 5, Period: time.Minute}, limit)
+
+	limit, err = ParseLimit("")
+	require.NoError(t, err)
+	assert.False(t, limit.Enabled())
+
+	for _, value := range []string{"5", "0/1m", "x/1m", "5/soon", "5/-1s"} {
+		_, err := ParseLimit(value)
+		assert.ErrorIs(t, err, ErrInvalidLimit, value)
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Period: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		result, err := store.Take(ctx, "k", limit, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := store.Take(ctx, "k", limit, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	result, err = store.Take(ctx, "other", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(ctx, "k", limit, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewLimiter(NewMemoryStore())
+	router := gin.New()
+	router.POST("/contacts", limiter.Middleware(Rule{
+		Name:     "contacts",
+		PerIP:    Limit{Burst: 3, Period: time.Minute},
+		PerEmail: Limit{Burst: 1, Period: time.Hour},
+	}), func(c *gin.Context) {
+		var body struct {
+			Email string `json:"email"`
+		}
+		require.NoError(t, c.ShouldBindJSON(&body))
+		c.String(http.StatusCreated, body.Email)
+	})
+
+	post := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(`{"email":"`+email+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("a@example.com")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "a@example.com", w.Body.String(), "body is left for the handler")
+
+	w = post("A@Example.com")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusCreated, post("b@example.com").Code)
+
+	w = post("c@example.com")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+}
+
+func TestMiddlewareReadsEmailWhateverTheContentType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewLimiter(NewMemoryStore())
+	router := gin.New()
+	router.POST("/contacts", limiter.Middleware(Rule{
+		Name:     "contacts",
+		PerEmail: Limit{Burst: 1, Period: time.Hour},
+	}), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	post := func(contentType, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, post("application/json", `{"email":"a@example.com"}`))
+	assert.Equal(t, http.StatusTooManyRequests, post("text/plain", `{"email":"a@example.com"}`))
+	assert.Equal(t, http.StatusTooManyRequests, post("", `{"Email":"A@example.com"}`))
+
+	large := `{"email":"b@example.com","message":"` + strings.Repeat("x", MaxBodySize) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("application/json", large))
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrAdminNotFound = errors.New("admin not found")
+	ErrAdminExists   = errors.New("admin already exists")
+	// ErrTOTPEnrollmentChanged means the pending authenticator secret is
+	// no longer the one that was confirmed.
+	ErrTOTPEnrollmentChanged = errors.New("authenticator enrollment was restarted")
+	ErrLastOwner             = errors.New("the last owner can't be removed")
+)
+
+// AdminRole decides what an admin may do. Each role can do everything the
+// roles below it can.
+type AdminRole string
+
+const (
+	// AdminRoleViewer can read contacts, people and bookings.
+	AdminRoleViewer AdminRole = "viewer"
+	// AdminRoleEditor can also change them.
+	AdminRoleEditor AdminRole = "editor"
+	// AdminRoleOwner can also manage admins and erase personal data.
+	AdminRoleOwner AdminRole = "owner"
+)
+
+var adminRoleRanks = map[AdminRole]int{
+	AdminRoleViewer: 1,
+	AdminRoleEditor: 2,
+	AdminRoleOwner:  3,
+}
+
+func (r AdminRole) IsValid() bool {
+	_, ok := adminRoleRanks[r]
+	return ok
+}
+
+// Allows reports whether the role grants at least min.
+func (r AdminRole) Allows(min AdminRole) bool {
+	return r.IsValid() && adminRoleRanks[r] >= adminRoleRanks[min]
+}
+
+// LoginPolicy decides which second factors an admin may sign in with.
+type LoginPolicy string
+
+const (
+	// LoginPolicyEmail accepts only emailed codes. It is the default.
+	LoginPolicyEmail LoginPolicy = "email"
+	// LoginPolicyEither accepts an emailed code or an authenticator code.
+	LoginPolicyEither LoginPolicy = "either"
+	// LoginPolicyTOTP accepts only authenticator and recovery codes, so
+	// no codes are emailed.
+	LoginPolicyTOTP LoginPolicy = "totp"
+)
+
+func (p LoginPolicy) IsValid() bool {
+	switch p {
+	case LoginPolicyEmail, LoginPolicyEither, LoginPolicyTOTP:
+		return true
+	}
+	return false
+}
+
+// AllowsEmail reports whether emailed codes are accepted. Admins stored
+// before policies existed have none and use email.
+func (p LoginPolicy) AllowsEmail() bool {
+	return p != LoginPolicyTOTP
+}
+
+// AllowsTOTP reports whether authenticator codes are accepted.
+func (p LoginPolicy) AllowsTOTP() bool {
+	return p == LoginPolicyEither || p == LoginPolicyTOTP
+}
+
+// Admin is someone allowed to sign in to the admin area, keyed by
+// normalized email.
+type Admin struct {
+	Email     string    `bson:"_id"`
+	Role      AdminRole `bson:"role"`
+	InvitedBy string    `bson:"invited_by,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+
+	LoginPolicy LoginPolicy `bson:"login_policy,omitempty"`
+	// TOTPSecret is the encrypted authenticator secret once enrollment is
+	// confirmed; TOTPPendingSecret holds it until then.
+	TOTPSecret        string `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string `bson:"totp_pending_secret,omitempty" json:"-"`
+	// TOTPLastStep is the last time step a code was accepted for, so a
+	// code can't be replayed.
+	TOTPLastStep int64 `bson:"totp_last_step,omitempty" json:"-"`
+	// RecoveryCodeHashes are the hashes of unused one-time recovery codes.
+	RecoveryCodeHashes []string `bson:"recovery_code_hashes,omitempty" json:"-"`
+}
+
+// TOTPEnabled reports whether the admin has confirmed an authenticator.
+func (a Admin) TOTPEnabled() bool {
+	return a.TOTPSecret != ""
+}
+
+type AdminRepository interface {
+	// CreateAdmin returns ErrAdminExists if the email is already an admin.
+	CreateAdmin(ctx context.Context, admin *Admin) error
+	GetAdmin(ctx context.Context, email string) (Admin, error)
+	GetAdmins(ctx context.Context) ([]Admin, error)
+	// DeleteAdmin returns ErrLastOwner rather than remove the only owner,
+	// even when owners are removed concurrently.
+	DeleteAdmin(ctx context.Context, email string) error
+	// EnsureOwner makes email an owner, creating the admin if needed.
+	EnsureOwner(ctx context.Context, email string) error
+	// SetPendingTOTP starts authenticator enrollment with a sealed secret.
+	SetPendingTOTP(ctx context.Context, email, sealedSecret string) error
+	// EnableTOTP makes the pending secret active, replacing any earlier one
+	// and its recovery codes, and sets the login policy. usedStep is the
+	// step of the code that confirmed it, which can't then sign in. It
+	// returns ErrTOTPEnrollmentChanged unless the pending secret is still
+	// sealedSecret, the one the code was checked against.
+	EnableTOTP(ctx context.Context, email, sealedSecret string, usedStep int64, recoveryCodeHashes []string, policy LoginPolicy) error
+	// DisableTOTP removes the authenticator and goes back to emailed codes.
+	DisableTOTP(ctx context.Context, email string) error
+	SetLoginPolicy(ctx context.Context, email string, policy LoginPolicy) error
+	// UseTOTPStep records that a code for step was accepted. It returns false
+	// if that step or a later one was already used.
+	UseTOTPStep(ctx context.Context, email string, step int64) (bool, error)
+	// UseRecoveryCode removes a recovery code, returning false if it was
+	// not one of the admin's unused codes.
+	UseRecoveryCode(ctx context.Context, email, codeHash string) (bool, error)
+}
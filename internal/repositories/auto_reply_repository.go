@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+type AutoReplyRepository interface {
+	// ClaimAutoReply records that email is being sent an auto-reply at now,
+	// unless it already had one within window. It reports whether the claim
+	// succeeded, so concurrent submissions from the same sender only send
+	// one reply.
+	ClaimAutoReply(ctx context.Context, email string, now time.Time, window time.Duration) (bool, error)
+	// GetLastAutoReply returns when email was last auto-replied to, or nil.
+	GetLastAutoReply(ctx context.Context, email string) (*time.Time, error)
+	DeleteAutoReply(ctx context.Context, email string) error
+}
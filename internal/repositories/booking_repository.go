@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrBookingNotFound = errors.New("booking inquiry not found")
+
+// Booking is a stored booking inquiry.
+type Booking struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Email     string             `bson:"email"`
+	Phone     string             `bson:"phone,omitempty"`
+	EventType string             `bson:"event_type"`
+	// EventDate is midnight UTC on the day of the event.
+	EventDate time.Time `bson:"event_date"`
+	Venue     string    `bson:"venue"`
+	City      string    `bson:"city"`
+	Capacity  int       `bson:"capacity,omitempty"`
+	Budget    string    `bson:"budget,omitempty"`
+	Message   string    `bson:"message,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+
+	// Spam is set on inquiries quarantined by the spam checks, with the
+	// score and reasons explaining why.
+	Spam        bool     `bson:"spam,omitempty"`
+	SpamScore   int      `bson:"spam_score,omitempty"`
+	SpamReasons []string `bson:"spam_reasons,omitempty"`
+}
+
+// BookingFilter narrows a booking listing. EventFrom and EventTo bound the
+// event date, inclusive and exclusive.
+type BookingFilter struct {
+	EventFrom *time.Time
+	EventTo   *time.Time
+	EventType string
+	// Spam lists quarantined inquiries instead of genuine ones.
+	Spam   bool
+	Limit  int
+	Offset int
+}
+
+type BookingRepository interface {
+	CreateBooking(ctx context.Context, booking *Booking) error
+	// GetBookings lists bookings, newest inquiry first, with the total
+	// matching the filter.
+	GetBookings(ctx context.Context, filter BookingFilter) ([]Booking, int64, error)
+	GetBookingByID(ctx context.Context, id string) (Booking, error)
+	// GetBookingsByEmail lists every booking from the email, ignoring case.
+	GetBookingsByEmail(ctx context.Context, email string) ([]Booking, error)
+	DeleteBookingsByEmail(ctx context.Context, email string) (int64, error)
+}
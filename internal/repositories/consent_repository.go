@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConsentEvent is the step of a signup a consent record proves.
+type ConsentEvent string
+
+const (
+	// ConsentSignup is the sender agreeing on the form.
+	ConsentSignup ConsentEvent = "signup"
+	// ConsentConfirmation is the sender following a double opt-in link.
+	ConsentConfirmation ConsentEvent = "confirmation"
+)
+
+// RedactedConsentText replaces consent wording that mentioned an erased
+// address.
+const RedactedConsentText = "[redacted]"
+
+// Consent is proof that someone agreed to join the mailing list. Consent
+// records are write-once: the only change the repository allows is
+// anonymizing them when the person asks to be erased.
+type Consent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ContactID primitive.ObjectID `bson:"contact_id"`
+	// Email is normalized.
+	Email string       `bson:"email"`
+	Event ConsentEvent `bson:"event"`
+	// Text is the consent wording shown to the person.
+	Text      string    `bson:"text"`
+	Source    string    `bson:"source"`
+	IP        string    `bson:"ip,omitempty"`
+	UserAgent string    `bson:"user_agent,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type ConsentRepository interface {
+	RecordConsent(ctx context.Context, consent *Consent) error
+	// GetConsentsByEmails lists consents given from any of the normalized
+	// addresses, oldest first.
+	GetConsentsByEmails(ctx context.Context, emails []string) ([]Consent, error)
+	// AnonymizeConsents replaces the email on a person's consents with
+	// pseudonym, redacts any wording that mentions the address and drops
+	// the IP address and user agent, keeping the record that consent was
+	// given.
+	AnonymizeConsents(ctx context.Context, email, pseudonym string) (int64, error)
+}
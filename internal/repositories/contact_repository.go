@@ -2,23 +2,355 @@ package repositories
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrContactNotFound   = errors.New("contact not found")
+	ErrStatusChanged     = errors.New("contact status was changed by someone else")
+	ErrVersionConflict   = errors.New("contact was modified by someone else")
+	ErrContactNotTrashed = errors.New("contact is not in the trash")
+)
+
+// ContactStatus tracks where a submission is in the admin inbox workflow.
+// Contacts stored before statuses existed have no status and are treated as
+// new.
+type ContactStatus string
+
+const (
+	ContactStatusNew      ContactStatus = "new"
+	ContactStatusRead     ContactStatus = "read"
+	ContactStatusReplied  ContactStatus = "replied"
+	ContactStatusArchived ContactStatus = "archived"
+	ContactStatusSpam     ContactStatus = "spam"
+)
+
+var ContactStatuses = []ContactStatus{
+	ContactStatusNew,
+	ContactStatusRead,
+	ContactStatusReplied,
+	ContactStatusArchived,
+	ContactStatusSpam,
+}
+
+func (s ContactStatus) IsValid() bool {
+	for _, status := range ContactStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ContactCategory is what a submission is about, chosen by the sender on the
+// public form. Contacts stored before categories existed are general.
+type ContactCategory string
+
+const (
+	ContactCategoryGeneral   ContactCategory = "general"
+	ContactCategoryBooking   ContactCategory = "booking"
+	ContactCategoryPress     ContactCategory = "press"
+	ContactCategoryFanMail   ContactCategory = "fan_mail"
+	ContactCategoryLicensing ContactCategory = "licensing"
+)
+
+var ContactCategories = []ContactCategory{
+	ContactCategoryGeneral,
+	ContactCategoryBooking,
+	ContactCategoryPress,
+	ContactCategoryFanMail,
+	ContactCategoryLicensing,
+}
+
+func (c ContactCategory) IsValid() bool {
+	for _, category := range ContactCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// TagCount is how many live contacts carry a tag.
+type TagCount struct {
+	Tag   string `bson:"_id" json:"tag"`
+	Count int64  `bson:"count" json:"count"`
+}
+
+// ContactReply is an email an admin sent in answer to a contact.
+type ContactReply struct {
+	Subject string    `bson:"subject"`
+	Body    string    `bson:"body"`
+	SentBy  string    `bson:"sent_by"`
+	SentAt  time.Time `bson:"sent_at"`
+}
+
+// MailchimpSyncStatus tracks subscribing a contact to the Mailchimp list.
+type MailchimpSyncStatus string
+
+const (
+	MailchimpSyncPending MailchimpSyncStatus = "pending"
+	MailchimpSyncSynced  MailchimpSyncStatus = "synced"
+	// MailchimpSyncFailed means the sync gave up after too many attempts.
+	// An admin can queue it again.
+	MailchimpSyncFailed MailchimpSyncStatus = "failed"
+)
+
+var MailchimpSyncStatuses = []MailchimpSyncStatus{
+	MailchimpSyncPending,
+	MailchimpSyncSynced,
+	MailchimpSyncFailed,
+}
+
+func (s MailchimpSyncStatus) IsValid() bool {
+	for _, status := range MailchimpSyncStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// MailchimpSync is the state of a contact's Mailchimp subscription, which is
+// synced in the background after the contact is stored.
+type MailchimpSync struct {
+	Status        MailchimpSyncStatus `bson:"status"`
+	Attempts      int                 `bson:"attempts"`
+	LastError     string              `bson:"last_error,omitempty"`
+	LastAttemptAt *time.Time          `bson:"last_attempt_at,omitempty"`
+	// NextAttemptAt is when a pending sync is next due.
+	NextAttemptAt *time.Time `bson:"next_attempt_at,omitempty"`
+	SyncedAt      *time.Time `bson:"synced_at,omitempty"`
+}
+
+// ContactSource records how a contact entered Chanterelle.
+type ContactSource string
+
+const (
+	ContactSourceForm   ContactSource = "form"
+	ContactSourceImport ContactSource = "import"
+)
+
+// StatusChange records one transition of a contact's status.
+type StatusChange struct {
+	From      ContactStatus `bson:"from"`
+	To        ContactStatus `bson:"to"`
+	ChangedBy string        `bson:"changed_by"`
+	ChangedAt time.Time     `bson:"changed_at"`
+}
+
+// ContactSearchWeights ranks matches in each searchable field. The Mongo
+// text index and the scanning fallback use the same weights.
+var ContactSearchWeights = map[string]int{
+	"name":    5,
+	"email":   5,
+	"message": 1,
+}
+
 type Contact struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Name      string            `bson:"name"`
-	Email     string            `bson:"email"`
-	Message   string            `bson:"message"`
-	CreatedAt time.Time         `bson:"created_at"`
+	Name      string             `bson:"name"`
+	Email     string             `bson:"email"`
+	Message   string             `bson:"message"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt *time.Time         `bson:"updated_at,omitempty"`
+	// NormalizedEmail is Email trimmed and lower-cased, so exact lookups by
+	// email can use an index.
+	NormalizedEmail string `bson:"normalized_email,omitempty" json:"-"`
+	// Version is incremented on every change and used as the precondition
+	// for optimistic concurrency. Contacts stored before versioning are 0.
+	Version int `bson:"version"`
+	// Source records how the contact entered Chanterelle.
+	Source ContactSource `bson:"source,omitempty"`
+	// Category is what the sender said the message is about.
+	Category ContactCategory `bson:"category,omitempty"`
+	// PersonID links the submission to everyone else's submissions from the
+	// same person.
+	PersonID *primitive.ObjectID `bson:"person_id,omitempty"`
+
+	Status          ContactStatus  `bson:"status"`
+	StatusChangedAt *time.Time     `bson:"status_changed_at,omitempty"`
+	StatusChangedBy string         `bson:"status_changed_by,omitempty"`
+	StatusHistory   []StatusChange `bson:"status_history,omitempty"`
+
+	// Replies are the emails sent back to the contact, oldest first.
+	Replies []ContactReply `bson:"replies,omitempty"`
+
+	// Tags are lower-cased labels admins attach to a contact.
+	Tags []string `bson:"tags,omitempty"`
+
+	// SpamScore and SpamReasons explain why a form submission was
+	// quarantined as spam.
+	SpamScore   int      `bson:"spam_score,omitempty"`
+	SpamReasons []string `bson:"spam_reasons,omitempty"`
+
+	// MailchimpSync is set once the contact is queued for Mailchimp.
+	MailchimpSync *MailchimpSync `bson:"mailchimp_sync,omitempty"`
+
+	// DeletedAt is set while the contact is in the trash.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty"`
+}
+
+// ContactUpdate is a partial update of a contact. Nil fields are left
+// unchanged. The update only applies if the contact is still at
+// ExpectedVersion.
+type ContactUpdate struct {
+	Name            *string
+	Email           *string
+	Message         *string
+	ExpectedVersion int
+}
+
+// ContactFilter narrows and orders a contact listing. CreatedFrom is
+// inclusive and CreatedTo is exclusive; Email and Name match
+// case-insensitive substrings.
+type ContactFilter struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Email       string
+	Name        string
+	Status      ContactStatus
+	Category    ContactCategory
+	// MailchimpStatus limits the results to contacts whose Mailchimp sync
+	// is in the given state.
+	MailchimpStatus MailchimpSyncStatus
+	// Tags limits the results to contacts carrying every one of the tags.
+	Tags     []string
+	PersonID *primitive.ObjectID
+	// WithoutPerson limits the results to contacts not yet linked to a
+	// person.
+	WithoutPerson bool
+	// Trashed lists contacts in the trash instead of live contacts.
+	Trashed   bool
+	Ascending bool
+	Cursor    string
+	Limit     int
+}
+
+// ContactPage is one page of a contact listing. NextCursor is empty when
+// there are no more results.
+type ContactPage struct {
+	Contacts   []Contact
+	Total      int64
+	NextCursor string
+}
+
+// ContactCursor marks the position of the last contact on a page. Contacts
+// are ordered by creation time with the ObjectID as a tie-breaker.
+type ContactCursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+func (c ContactCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%s", c.CreatedAt.UnixNano(), c.ID.Hex())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeContactCursor(token string) (ContactCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ContactCursor{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return ContactCursor{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ContactCursor{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return ContactCursor{}, ErrInvalidCursor
+	}
+	return ContactCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// ContactSearchResult is a contact matched by a full-text search together
+// with its relevance score.
+type ContactSearchResult struct {
+	Contact Contact
+	Score   float64
+}
+
+// ContactSearcher is implemented by repositories that can run ranked
+// full-text search natively. Repositories without it are searched by
+// scanning the listing instead.
+type ContactSearcher interface {
+	SearchContacts(ctx context.Context, query string, limit int) ([]ContactSearchResult, error)
 }
 
 type ContactRepository interface {
-	CreateContact(ctx context.Context, name, email, message string) error
-	GetContacts(ctx context.Context) ([]Contact, error)
+	// CreateContact stores a new contact, filling in its ID, creation time
+	// and initial status.
+	CreateContact(ctx context.Context, contact *Contact) error
+	// ContactExistsByEmail reports whether any contact outside the trash
+	// uses the email address, ignoring case.
+	ContactExistsByEmail(ctx context.Context, email string) (bool, error)
+	GetContacts(ctx context.Context, filter ContactFilter) (ContactPage, error)
+	// StreamContacts calls fn for every contact matching the filter, in
+	// order, without loading them all into memory. Cursor and Limit are
+	// ignored. Iteration stops at the first error returned by fn.
+	StreamContacts(ctx context.Context, filter ContactFilter, fn func(Contact) error) error
+	// GetContactByID returns ErrContactNotFound for contacts in the trash,
+	// as do the methods below that change a contact, except RestoreContact.
 	GetContactByID(ctx context.Context, id string) (Contact, error)
-	UpdateContact(ctx context.Context, id string, name, email, message string) error
-	DeleteContact(ctx context.Context, id string) error
+	// UpdateContact applies a partial update and returns the updated
+	// contact. It returns ErrVersionConflict if the contact has changed since
+	// update.ExpectedVersion.
+	UpdateContact(ctx context.Context, id string, update ContactUpdate) (Contact, error)
+	// DeleteContact moves a contact to the trash.
+	DeleteContact(ctx context.Context, id, deletedBy string) error
+	// RestoreContact takes a contact back out of the trash. It returns
+	// ErrContactNotTrashed if the contact is not in the trash.
+	RestoreContact(ctx context.Context, id string) (Contact, error)
+	// GetContactsTrashedBefore lists up to limit contacts trashed before the
+	// given time, longest trashed first.
+	GetContactsTrashedBefore(ctx context.Context, trashedBefore time.Time, limit int) ([]Contact, error)
+	// DeleteTrashedContacts permanently removes the given contacts if they
+	// are still in the trash since before the given time, and returns the
+	// ones removed. removeRelated is called with the same ctx and the ids
+	// removed to delete what is stored about them elsewhere. Either all of
+	// it happens or none of it.
+	DeleteTrashedContacts(ctx context.Context, ids []primitive.ObjectID, trashedBefore time.Time, removeRelated func(ctx context.Context, ids []primitive.ObjectID) error) ([]Contact, error)
+	// UpdateContactStatus moves a contact from one status to another. It
+	// returns ErrStatusChanged if the contact is no longer in the from
+	// status.
+	UpdateContactStatus(ctx context.Context, id string, change StatusChange) (Contact, error)
+	// AddContactReply records a reply sent to the contact. If change is not
+	// nil the status moves too, and ErrStatusChanged is returned without
+	// recording anything if the contact is no longer in change.From.
+	AddContactReply(ctx context.Context, id string, reply ContactReply, change *StatusChange) (Contact, error)
+	AddContactTags(ctx context.Context, id string, tags []string) (Contact, error)
+	RemoveContactTag(ctx context.Context, id, tag string) (Contact, error)
+	// GetTags lists tags starting with prefix, most used first.
+	GetTags(ctx context.Context, prefix string, limit int) ([]TagCount, error)
+	SetContactPerson(ctx context.Context, id, personID primitive.ObjectID) error
+	// MoveContactsToPerson relinks every contact of one person to another and
+	// returns how many were moved.
+	MoveContactsToPerson(ctx context.Context, fromPersonID, toPersonID primitive.ObjectID) (int64, error)
+	CountContactsByStatus(ctx context.Context) (map[ContactStatus]int64, error)
+	// SetMailchimpSync replaces a contact's Mailchimp sync state. Sync
+	// bookkeeping doesn't change the contact's version.
+	SetMailchimpSync(ctx context.Context, id primitive.ObjectID, sync MailchimpSync) error
+	// ClaimMailchimpSync picks a pending sync that is due at now and pushes
+	// its next attempt to leaseUntil, so other workers skip it while it is
+	// processed. It returns ErrContactNotFound when nothing is due.
+	ClaimMailchimpSync(ctx context.Context, now, leaseUntil time.Time) (Contact, error)
+	CountMailchimpSyncs(ctx context.Context) (map[MailchimpSyncStatus]int64, error)
+	// GetContactsByEmail lists every contact using the email address,
+	// ignoring case, including contacts in the trash.
+	GetContactsByEmail(ctx context.Context, email string) ([]Contact, error)
+	// DeleteContactsByIDs permanently removes contacts, bypassing the trash.
+	DeleteContactsByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error)
 }
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContactCursorRoundTrip(t *testing.T) {
+	cursor := ContactCursor{
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC),
+		ID:        primitive.NewObjectID(),
+	}
+
+	decoded, err := DecodeContactCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestDecodeContactCursorRejectsInvalidTokens(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tokens := []string{
+		"not base64!",
+		encode("1714564800000000000"),
+		encode("yesterday:" + primitive.NewObjectID().Hex()),
+		encode("1714564800000000000:not-an-id"),
+	}
+	for _, token := range tokens {
+		_, err := DecodeContactCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// FormTokenRepository remembers the nonces of submitted form tokens, so
+// each token served with a public form can only be submitted once.
+type FormTokenRepository interface {
+	// UseFormToken records nonce as used until expiresAt. It reports
+	// whether the nonce was unused, so concurrent submissions of the same
+	// token only succeed once.
+	UseFormToken(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
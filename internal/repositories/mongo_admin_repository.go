@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAdminRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAdminRepository(db *mongo.Database) *MongoAdminRepository {
+	return &MongoAdminRepository{
+		collection: db.Collection("admins"),
+	}
+}
+
+func (r *MongoAdminRepository) CreateAdmin(ctx context.Context, admin *Admin) error {
+	admin.CreatedAt = time.Now()
+	if _, err := r.collection.InsertOne(ctx, admin); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAdminExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *MongoAdminRepository) GetAdmin(ctx context.Context, email string) (Admin, error) {
+	var admin Admin
+	if err := r.collection.FindOne(ctx, bson.M{"_id": email}).Decode(&admin); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Admin{}, ErrAdminNotFound
+		}
+		return Admin{}, err
+	}
+	return admin, nil
+}
+
+func (r *MongoAdminRepository) GetAdmins(ctx context.Context) ([]Admin, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	admins := []Admin{}
+	if err := cursor.All(ctx, &admins); err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+func (r *MongoAdminRepository) DeleteAdmin(ctx context.Context, email string) error {
+	// Removing an owner stamps every remaining owner in the same
+	// transaction. Two removals racing for the last two owners then write
+	// to each other's document, so one conflicts and retries, and finds no
+	// owner left but itself.
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		var admin Admin
+		if err := r.collection.FindOneAndDelete(sc, bson.M{"_id": email}).Decode(&admin); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrAdminNotFound
+			}
+			return nil, err
+		}
+		if admin.Role != AdminRoleOwner {
+			return nil, nil
+		}
+
+		result, err := r.collection.UpdateMany(sc,
+			bson.M{"role": AdminRoleOwner},
+			bson.M{"$currentDate": bson.M{"owner_checked_at": true}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, ErrLastOwner
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (r *MongoAdminRepository) EnsureOwner(ctx context.Context, email string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": email},
+		bson.M{
+			"$set":         bson.M{"role": AdminRoleOwner},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoAdminRepository) SetPendingTOTP(ctx context.Context, email, sealedSecret string) error {
+	return r.update(ctx, bson.M{"_id": email}, bson.M{"$set": bson.M{"totp_pending_secret": sealedSecret}})
+}
+
+func (r *MongoAdminRepository) EnableTOTP(ctx context.Context, email, sealedSecret string, usedStep int64, recoveryCodeHashes []string, policy LoginPolicy) error {
+	// An update pipeline so the pending secret can be moved in one step.
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"totp_secret":          "$totp_pending_secret",
+			"recovery_code_hashes": recoveryCodeHashes,
+			"login_policy":         policy,
+			"totp_last_step":       usedStep,
+		}}},
+		{{Key: "$unset", Value: "totp_pending_secret"}},
+	}
+	filter := bson.M{"_id": email, "totp_pending_secret": sealedSecret}
+	if err := r.update(ctx, filter, pipeline); err != nil {
+		if errors.Is(err, ErrAdminNotFound) {
+			return ErrTOTPEnrollmentChanged
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *MongoAdminRepository) DisableTOTP(ctx context.Context, email string) error {
+	return r.update(ctx, bson.M{"_id": email}, bson.M{
+		"$set": bson.M{"login_policy": LoginPolicyEmail},
+		"$unset": bson.M{
+			"totp_secret":          "",
+			"totp_pending_secret":  "",
+			"totp_last_step":       "",
+			"recovery_code_hashes": "",
+		},
+	})
+}
+
+func (r *MongoAdminRepository) SetLoginPolicy(ctx context.Context, email string, policy LoginPolicy) error {
+	return r.update(ctx, bson.M{"_id": email}, bson.M{"$set": bson.M{"login_policy": policy}})
+}
+
+func (r *MongoAdminRepository) UseTOTPStep(ctx context.Context, email string, step int64) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": email, "$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+		}},
+		bson.M{"$set": bson.M{"totp_last_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *MongoAdminRepository) UseRecoveryCode(ctx context.Context, email, codeHash string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": email, "recovery_code_hashes": codeHash},
+		bson.M{"$pull": bson.M{"recovery_code_hashes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// update applies an update to one admin, returning ErrAdminNotFound if
+// none matched.
+func (r *MongoAdminRepository) update(ctx context.Context, filter bson.M, update interface{}) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAdminNotFound
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoDeleteAdminKeepsLastOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoAdminRepository(newTestDatabase(t))
+
+	require.NoError(t, repo.EnsureOwner(ctx, "first@example.com"))
+	require.NoError(t, repo.EnsureOwner(ctx, "second@example.com"))
+	require.NoError(t, repo.CreateAdmin(ctx, &Admin{Email: "editor@example.com", Role: AdminRoleEditor}))
+
+	require.NoError(t, repo.DeleteAdmin(ctx, "editor@example.com"))
+	assert.ErrorIs(t, repo.DeleteAdmin(ctx, "editor@example.com"), ErrAdminNotFound)
+
+	// Removing both owners at once leaves one of them.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, email := range []string{"first@example.com", "second@example.com"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = repo.DeleteAdmin(ctx, email)
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []error{nil, ErrLastOwner}, errs)
+	admins, err := repo.GetAdmins(ctx)
+	require.NoError(t, err)
+	require.Len(t, admins, 1)
+	assert.Equal(t, AdminRoleOwner, admins[0].Role)
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAutoReplyRepository keeps one document per sender email with the
+// time they were last auto-replied to.
+type MongoAutoReplyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAutoReplyRepository(db *mongo.Database) *MongoAutoReplyRepository {
+	return &MongoAutoReplyRepository{
+		collection: db.Collection("auto_replies"),
+	}
+}
+
+// EnsureIndexes expires senders once their suppression window is over.
+func (r *MongoAutoReplyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoAutoReplyRepository) ClaimAutoReply(ctx context.Context, email string, now time.Time, window time.Duration) (bool, error) {
+	// A sender replied to within the window doesn't match the filter, so the
+	// upsert tries to insert a second document with the same _id and fails.
+	filter := bson.M{"_id": email, "last_sent_at": bson.M{"$lte": now.Add(-window)}}
+	update := bson.M{"$set": bson.M{"last_sent_at": now, "expires_at": now.Add(window)}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *MongoAutoReplyRepository) GetLastAutoReply(ctx context.Context, email string) (*time.Time, error) {
+	var record struct {
+		LastSentAt time.Time `bson:"last_sent_at"`
+	}
+	if err := r.collection.FindOne(ctx, bson.M{"_id": email}).Decode(&record); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record.LastSentAt, nil
+}
+
+func (r *MongoAutoReplyRepository) DeleteAutoReply(ctx context.Context, email string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": email})
+	return err
+}
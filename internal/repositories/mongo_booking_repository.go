@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoBookingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoBookingRepository(db *mongo.Database) *MongoBookingRepository {
+	return &MongoBookingRepository{
+		collection: db.Collection("booking_inquiries"),
+	}
+}
+
+func (r *MongoBookingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "event_date", Value: 1}}},
+	})
+	return err
+}
+
+func (r *MongoBookingRepository) CreateBooking(ctx context.Context, booking *Booking) error {
+	booking.ID = primitive.NewObjectID()
+	booking.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, booking)
+	return err
+}
+
+func (r *MongoBookingRepository) GetBookings(ctx context.Context, filter BookingFilter) ([]Booking, int64, error) {
+	query := bson.M{}
+	eventDate := bson.M{}
+	if filter.EventFrom != nil {
+		eventDate["$gte"] = *filter.EventFrom
+	}
+	if filter.EventTo != nil {
+		eventDate["$lt"] = *filter.EventTo
+	}
+	if len(eventDate) > 0 {
+		query["event_date"] = eventDate
+	}
+	if filter.EventType != "" {
+		query["event_type"] = filter.EventType
+	}
+	if filter.Spam {
+		query["spam"] = true
+	} else {
+		query["spam"] = bson.M{"$ne": true}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(filter.Offset)).
+		SetLimit(int64(filter.Limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, 0, err
+	}
+	return bookings, total, nil
+}
+
+func (r *MongoBookingRepository) GetBookingByID(ctx context.Context, id string) (Booking, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Booking{}, ErrBookingNotFound
+	}
+	var booking Booking
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&booking); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Booking{}, ErrBookingNotFound
+		}
+		return Booking{}, err
+	}
+	return booking, nil
+}
+
+func (r *MongoBookingRepository) GetBookingsByEmail(ctx context.Context, email string) ([]Booking, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"email": emailQuery(email)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *MongoBookingRepository) DeleteBookingsByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"email": emailQuery(email)})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoConsentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoConsentRepository(db *mongo.Database) *MongoConsentRepository {
+	return &MongoConsentRepository{
+		collection: db.Collection("consents"),
+	}
+}
+
+func (r *MongoConsentRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "contact_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *MongoConsentRepository) RecordConsent(ctx context.Context, consent *Consent) error {
+	consent.ID = primitive.NewObjectID()
+	consent.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, consent)
+	return err
+}
+
+func (r *MongoConsentRepository) GetConsentsByEmails(ctx context.Context, emails []string) ([]Consent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"email": bson.M{"$in": emails}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	consents := []Consent{}
+	if err := cursor.All(ctx, &consents); err != nil {
+		return nil, err
+	}
+	return consents, nil
+}
+
+func (r *MongoConsentRepository) AnonymizeConsents(ctx context.Context, email, pseudonym string) (int64, error) {
+	// Wording is matched ignoring case, as it may quote the address as it
+	// was typed rather than normalized.
+	mentionsEmail := bson.M{"$regexMatch": bson.M{
+		"input":   "$text",
+		"regex":   regexp.QuoteMeta(email),
+		"options": "i",
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"email": pseudonym,
+			"text":  bson.M{"$cond": bson.A{mentionsEmail, RedactedConsentText, "$text"}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"ip", "user_agent"}}},
+	}
+	result, err := r.collection.UpdateMany(ctx, bson.M{"email": email}, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
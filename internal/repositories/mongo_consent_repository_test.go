@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoAnonymizeConsents(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoConsentRepository(newTestDatabase(t))
+
+	for _, consent := range []*Consent{
+		{Email: "fan@example.com", Event: ConsentSignup, Text: "Yes please", IP: "203.0.113.7", UserAgent: "Firefox"},
+		{Email: "fan@example.com", Event: ConsentConfirmation, Text: "Confirmed by following the link emailed to Fan@Example.com"},
+		{Email: "sam@example.com", Event: ConsentSignup, Text: "Yes please", IP: "203.0.113.8"},
+	} {
+		require.NoError(t, repo.RecordConsent(ctx, consent))
+	}
+
+	anonymized, err := repo.AnonymizeConsents(ctx, "fan@example.com", "erased-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), anonymized)
+
+	consents, err := repo.GetConsentsByEmails(ctx, []string{"erased-1"})
+	require.NoError(t, err)
+	require.Len(t, consents, 2)
+	assert.Equal(t, "Yes please", consents[0].Text)
+	assert.Empty(t, consents[0].IP)
+	assert.Empty(t, consents[0].UserAgent)
+	assert.Equal(t, RedactedConsentText, consents[1].Text)
+
+	other, err := repo.GetConsentsByEmails(ctx, []string{"sam@example.com"})
+	require.NoError(t, err)
+	require.Len(t, other, 1)
+	assert.Equal(t, "203.0.113.8", other[0].IP)
+}
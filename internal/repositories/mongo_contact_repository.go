@@ -2,11 +2,14 @@ package repositories
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoContactRepository struct {
@@ -19,29 +22,250 @@ func NewMongoContactRepository(db *mongo.Database) *MongoContactRepository {
 	}
 }
 
-func (r *MongoContactRepository) CreateContact(ctx context.Context, name, email, message string) error {
-	contact := Contact{
-		Name:      name,
-		Email:     email,
-		Message:   message,
-		CreatedAt: time.Now(),
+// EnsureIndexes creates the indexes the contact queries rely on and
+// backfills the normalized email of contacts stored before it was recorded.
+// It is safe to call on every startup.
+func (r *MongoContactRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"normalized_email": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"normalized_email": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "normalized_email", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "person_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "mailchimp_sync.status", Value: 1},
+				{Key: "mailchimp_sync.next_attempt_at", Value: 1},
+			},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "email", Value: "text"},
+				{Key: "message", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("contacts_text").
+				SetWeights(bson.D{
+					{Key: "name", Value: ContactSearchWeights["name"]},
+					{Key: "email", Value: ContactSearchWeights["email"]},
+					{Key: "message", Value: ContactSearchWeights["message"]},
+				}),
+		},
+	})
+	return err
+}
+
+func (r *MongoContactRepository) CreateContact(ctx context.Context, contact *Contact) error {
+	contact.ID = primitive.NewObjectID()
+	contact.CreatedAt = time.Now()
+	contact.NormalizedEmail = normalizeEmail(contact.Email)
+	if contact.Status == "" {
+		contact.Status = ContactStatusNew
+	}
+	if contact.Category == "" {
+		contact.Category = ContactCategoryGeneral
 	}
 	_, err := r.collection.InsertOne(ctx, contact)
 	return err
 }
 
-func (r *MongoContactRepository) GetContacts(ctx context.Context) ([]Contact, error) {
-	cursor, err := r.collection.Find(ctx, bson.D{})
+// emailQuery matches an email address exactly, ignoring case.
+func emailQuery(email string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(email) + "$", "$options": "i"}
+}
+
+func (r *MongoContactRepository) ContactExistsByEmail(ctx context.Context, email string) (bool, error) {
+	filter := bson.M{"normalized_email": normalizeEmail(email), "deleted_at": nil}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *MongoContactRepository) GetContacts(ctx context.Context, filter ContactFilter) (ContactPage, error) {
+	query := contactFilterQuery(filter)
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return ContactPage{}, err
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := DecodeContactCursor(filter.Cursor)
+		if err != nil {
+			return ContactPage{}, err
+		}
+		query = bson.M{"$and": bson.A{query, contactCursorQuery(cursor, filter.Ascending)}}
+	}
+
+	opts := options.Find().
+		SetSort(contactSort(filter.Ascending)).
+		SetLimit(int64(filter.Limit + 1))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return ContactPage{}, err
 	}
 	defer cursor.Close(ctx)
 
-	var contacts []Contact
+	contacts := []Contact{}
 	if err := cursor.All(ctx, &contacts); err != nil {
+		return ContactPage{}, err
+	}
+	for i := range contacts {
+		normalizeContact(&contacts[i])
+	}
+
+	page := ContactPage{Total: total}
+	if len(contacts) > filter.Limit {
+		contacts = contacts[:filter.Limit]
+		last := contacts[len(contacts)-1]
+		page.NextCursor = ContactCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	page.Contacts = contacts
+	return page, nil
+}
+
+func (r *MongoContactRepository) StreamContacts(ctx context.Context, filter ContactFilter, fn func(Contact) error) error {
+	opts := options.Find().
+		SetSort(contactSort(filter.Ascending)).
+		SetBatchSize(200)
+
+	cursor, err := r.collection.Find(ctx, contactFilterQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var contact Contact
+		if err := cursor.Decode(&contact); err != nil {
+			return err
+		}
+		normalizeContact(&contact)
+		if err := fn(contact); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func contactSort(ascending bool) bson.D {
+	direction := -1
+	if ascending {
+		direction = 1
+	}
+	return bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}}
+}
+
+// contactFilterQuery builds the Mongo query for everything in the filter
+// except the cursor, so it can also be used for counting.
+func contactFilterQuery(filter ContactFilter) bson.M {
+	query := bson.M{"deleted_at": nil}
+	if filter.Trashed {
+		query["deleted_at"] = bson.M{"$ne": nil}
+	}
+
+	createdAt := bson.M{}
+	if filter.CreatedFrom != nil {
+		createdAt["$gte"] = *filter.CreatedFrom
+	}
+	if filter.CreatedTo != nil {
+		createdAt["$lt"] = *filter.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	if filter.Email != "" {
+		query["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Email), "$options": "i"}
+	}
+	if filter.Name != "" {
+		query["name"] = bson.M{"$regex": regexp.QuoteMeta(filter.Name), "$options": "i"}
+	}
+	if filter.Status != "" {
+		query["status"] = statusQuery(filter.Status)
+	}
+	if filter.Category != "" {
+		query["category"] = categoryQuery(filter.Category)
+	}
+	if filter.MailchimpStatus != "" {
+		query["mailchimp_sync.status"] = filter.MailchimpStatus
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	if filter.PersonID != nil {
+		query["person_id"] = *filter.PersonID
+	}
+	if filter.WithoutPerson {
+		query["person_id"] = nil
+	}
+
+	return query
+}
+
+// contactCursorQuery matches contacts that sort after the cursor position.
+func contactCursorQuery(cursor ContactCursor, ascending bool) bson.M {
+	op := "$lt"
+	if ascending {
+		op = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{op: cursor.CreatedAt}},
+		bson.M{"created_at": cursor.CreatedAt, "_id": bson.M{op: cursor.ID}},
+	}}
+}
+
+func (r *MongoContactRepository) SearchContacts(ctx context.Context, query string, limit int) ([]ContactSearchResult, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"$text": bson.M{"$search": query}, "deleted_at": nil}, opts)
+	if err != nil {
 		return nil, err
 	}
-	return contacts, nil
+	defer cursor.Close(ctx)
+
+	results := []ContactSearchResult{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			Contact `bson:",inline"`
+			Score   float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		normalizeContact(&doc.Contact)
+		results = append(results, ContactSearchResult{Contact: doc.Contact, Score: doc.Score})
+	}
+	return results, cursor.Err()
 }
 
 func (r *MongoContactRepository) GetContactByID(ctx context.Context, id string) (Contact, error) {
@@ -50,34 +274,464 @@ func (r *MongoContactRepository) GetContactByID(ctx context.Context, id string)
 		return Contact{}, err
 	}
 	var contact Contact
-	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&contact); err != nil {
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID, "deleted_at": nil}).Decode(&contact); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Contact{}, ErrContactNotFound
+		}
+		return Contact{}, err
+	}
+	normalizeContact(&contact)
+	return contact, nil
+}
+
+func (r *MongoContactRepository) UpdateContact(ctx context.Context, id string, update ContactUpdate) (Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
 		return Contact{}, err
 	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if update.Name != nil {
+		set["name"] = *update.Name
+	}
+	if update.Email != nil {
+		set["email"] = *update.Email
+		set["normalized_email"] = normalizeEmail(*update.Email)
+	}
+	if update.Message != nil {
+		set["message"] = *update.Message
+	}
+
+	filter := bson.M{"_id": objID, "deleted_at": nil, "version": versionQuery(update.ExpectedVersion)}
+	changes := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var contact Contact
+	if err := r.collection.FindOneAndUpdate(ctx, filter, changes, opts).Decode(&contact); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return Contact{}, err
+		}
+		if _, err := r.GetContactByID(ctx, id); err != nil {
+			return Contact{}, err
+		}
+		return Contact{}, ErrVersionConflict
+	}
+	normalizeContact(&contact)
 	return contact, nil
 }
 
-func (r *MongoContactRepository) UpdateContact(ctx context.Context, id string, name, email, message string) error {
+func (r *MongoContactRepository) DeleteContact(ctx context.Context, id, deletedBy string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{
-			{Key: "name", Value: name},
-			{Key: "email", Value: email},
-			{Key: "message", Value: message},
-			{Key: "updated_at", Value: time.Now()},
-		}},
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{"deleted_at": now, "deleted_by": deletedBy, "updated_at": now},
+		"$inc": bson.M{"version": 1},
 	}
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID, "deleted_at": nil}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+func (r *MongoContactRepository) RestoreContact(ctx context.Context, id string) (Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Contact{}, err
+	}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var contact Contact
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID, "deleted_at": bson.M{"$ne": nil}}, update, opts).Decode(&contact)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			return Contact{}, err
+		}
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objID})
+		if err != nil {
+			return Contact{}, err
+		}
+		if count == 0 {
+			return Contact{}, ErrContactNotFound
+		}
+		return Contact{}, ErrContactNotTrashed
+	}
+	normalizeContact(&contact)
+	return contact, nil
+}
+
+func (r *MongoContactRepository) GetContactsTrashedBefore(ctx context.Context, trashedBefore time.Time, limit int) ([]Contact, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": trashedBefore}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	contacts := []Contact{}
+	if err := cursor.All(ctx, &contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (r *MongoContactRepository) DeleteTrashedContacts(ctx context.Context, ids []primitive.ObjectID, trashedBefore time.Time, removeRelated func(ctx context.Context, ids []primitive.ObjectID) error) ([]Contact, error) {
+	// A contact restored after it is read conflicts with the delete, so the
+	// transaction retries rather than remove what is stored about a contact
+	// that stays.
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	purged, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{
+			"_id":        bson.M{"$in": ids},
+			"deleted_at": bson.M{"$lt": trashedBefore},
+		}
+		cursor, err := r.collection.Find(sc, filter)
+		if err != nil {
+			return nil, err
+		}
+		contacts := []Contact{}
+		if err := cursor.All(sc, &contacts); err != nil {
+			return nil, err
+		}
+		if len(contacts) == 0 {
+			return contacts, nil
+		}
+
+		purgedIDs := make([]primitive.ObjectID, len(contacts))
+		for i, contact := range contacts {
+			purgedIDs[i] = contact.ID
+		}
+		if _, err := r.collection.DeleteMany(sc, bson.M{"_id": bson.M{"$in": purgedIDs}}); err != nil {
+			return nil, err
+		}
+		if err := removeRelated(sc, purgedIDs); err != nil {
+			return nil, err
+		}
+		return contacts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged.([]Contact), nil
 }
 
-func (r *MongoContactRepository) DeleteContact(ctx context.Context, id string) error {
+func (r *MongoContactRepository) UpdateContactStatus(ctx context.Context, id string, change StatusChange) (Contact, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	filter := bson.M{"_id": objID, "deleted_at": nil, "status": statusQuery(change.From)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":            change.To,
+			"status_changed_at": change.ChangedAt,
+			"status_changed_by": change.ChangedBy,
+			"updated_at":        change.ChangedAt,
+		},
+		"$push": bson.M{"status_history": change},
+		"$inc":  bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var contact Contact
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&contact); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return Contact{}, err
+		}
+		if _, err := r.GetContactByID(ctx, id); err != nil {
+			return Contact{}, err
+		}
+		return Contact{}, ErrStatusChanged
+	}
+	return contact, nil
+}
+
+func (r *MongoContactRepository) CountContactsByStatus(ctx context.Context) (map[ContactStatus]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$status", ContactStatusNew}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[ContactStatus]int64, len(ContactStatuses))
+	for _, status := range ContactStatuses {
+		counts[status] = 0
+	}
+	for cursor.Next(ctx) {
+		var row struct {
+			Status ContactStatus `bson:"_id"`
+			Count  int64         `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Status] += row.Count
+	}
+	return counts, cursor.Err()
+}
+
+// statusQuery matches contacts in the given status. Contacts stored before
+// statuses existed have no status field and count as new.
+func statusQuery(status ContactStatus) interface{} {
+	if status == ContactStatusNew {
+		return bson.M{"$in": bson.A{ContactStatusNew, nil}}
+	}
+	return status
+}
+
+// categoryQuery matches contacts in the given category. Contacts stored
+// before categories existed have no category field and count as general.
+func categoryQuery(category ContactCategory) interface{} {
+	if category == ContactCategoryGeneral {
+		return bson.M{"$in": bson.A{ContactCategoryGeneral, nil}}
+	}
+	return category
+}
+
+func (r *MongoContactRepository) AddContactReply(ctx context.Context, id string, reply ContactReply, change *StatusChange) (Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	filter := bson.M{"_id": objID, "deleted_at": nil}
+	set := bson.M{"updated_at": reply.SentAt}
+	push := bson.M{"replies": reply}
+	if change != nil {
+		filter["status"] = statusQuery(change.From)
+		set["status"] = change.To
+		set["status_changed_at"] = change.ChangedAt
+		set["status_changed_by"] = change.ChangedBy
+		push["status_history"] = change
+	}
+	update := bson.M{"$set": set, "$push": push, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var contact Contact
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&contact); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return Contact{}, err
+		}
+		if _, err := r.GetContactByID(ctx, id); err != nil {
+			return Contact{}, err
+		}
+		return Contact{}, ErrStatusChanged
+	}
+	normalizeContact(&contact)
+	return contact, nil
+}
+
+func (r *MongoContactRepository) AddContactTags(ctx context.Context, id string, tags []string) (Contact, error) {
+	return r.updateContact(ctx, id, bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": tags}}})
+}
+
+func (r *MongoContactRepository) RemoveContactTag(ctx context.Context, id, tag string) (Contact, error) {
+	return r.updateContact(ctx, id, bson.M{"$pull": bson.M{"tags": tag}})
+}
+
+// updateContact applies an update to one contact, bumping its version, and
+// returns the result.
+func (r *MongoContactRepository) updateContact(ctx context.Context, id string, update bson.M) (Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Contact{}, err
+	}
+	update["$set"] = bson.M{"updated_at": time.Now()}
+	update["$inc"] = bson.M{"version": 1}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var contact Contact
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objID, "deleted_at": nil}, update, opts).Decode(&contact); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Contact{}, ErrContactNotFound
+		}
+		return Contact{}, err
+	}
+	normalizeContact(&contact)
+	return contact, nil
+}
+
+func (r *MongoContactRepository) GetTags(ctx context.Context, prefix string, limit int) ([]TagCount, error) {
+	match := bson.M{"tags": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": nil, "tags": match["tags"]}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []TagCount{}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *MongoContactRepository) SetContactPerson(ctx context.Context, id, personID primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"person_id": personID}})
 	if err != nil {
 		return err
 	}
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objID})
-	return err
+	if result.MatchedCount == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+func (r *MongoContactRepository) SetMailchimpSync(ctx context.Context, id primitive.ObjectID, sync MailchimpSync) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"mailchimp_sync": sync}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+func (r *MongoContactRepository) ClaimMailchimpSync(ctx context.Context, now, leaseUntil time.Time) (Contact, error) {
+	filter := bson.M{
+		"mailchimp_sync.status":          MailchimpSyncPending,
+		"mailchimp_sync.next_attempt_at": bson.M{"$lte": now},
+		"deleted_at":                     nil,
+	}
+	update := bson.M{"$set": bson.M{"mailchimp_sync.next_attempt_at": leaseUntil}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "mailchimp_sync.next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var contact Contact
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&contact); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Contact{}, ErrContactNotFound
+		}
+		return Contact{}, err
+	}
+	normalizeContact(&contact)
+	return contact, nil
+}
+
+func (r *MongoContactRepository) CountMailchimpSyncs(ctx context.Context) (map[MailchimpSyncStatus]int64, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"mailchimp_sync": bson.M{"$ne": nil}, "deleted_at": nil}}},
+		{{Key: "$group", Value: bson.M{"_id": "$mailchimp_sync.status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[MailchimpSyncStatus]int64, len(MailchimpSyncStatuses))
+	for _, status := range MailchimpSyncStatuses {
+		counts[status] = 0
+	}
+	for cursor.Next(ctx) {
+		var row struct {
+			Status MailchimpSyncStatus `bson:"_id"`
+			Count  int64               `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Status] += row.Count
+	}
+	return counts, cursor.Err()
+}
+
+func (r *MongoContactRepository) GetContactsByEmail(ctx context.Context, email string) ([]Contact, error) {
+	opts := options.Find().SetSort(contactSort(true))
+	cursor, err := r.collection.Find(ctx, bson.M{"normalized_email": normalizeEmail(email)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	contacts := []Contact{}
+	for cursor.Next(ctx) {
+		var contact Contact
+		if err := cursor.Decode(&contact); err != nil {
+			return nil, err
+		}
+		normalizeContact(&contact)
+		contacts = append(contacts, contact)
+	}
+	return contacts, cursor.Err()
+}
+
+func (r *MongoContactRepository) DeleteContactsByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoContactRepository) MoveContactsToPerson(ctx context.Context, fromPersonID, toPersonID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"person_id": fromPersonID},
+		bson.M{"$set": bson.M{"person_id": toPersonID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// versionQuery matches contacts at the given version. Contacts stored before
+// versioning have no version field and count as version 0.
+func versionQuery(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+func normalizeContact(contact *Contact) {
+	if contact.Status == "" {
+		contact.Status = ContactStatusNew
+	}
+	if contact.Category == "" {
+		contact.Category = ContactCategoryGeneral
+	}
+}
+
+// normalizeEmail is the form of an email stored in normalized_email.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
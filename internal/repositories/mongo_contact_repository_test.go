@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoTrashAndRestoreContact(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoContactRepository(newTestDatabase(t))
+	require.NoError(t, repo.EnsureIndexes(ctx))
+
+	contact := &Contact{Name: "Alex", Email: "Alex@Example.com"}
+	require.NoError(t, repo.CreateContact(ctx, contact))
+	id := contact.ID.Hex()
+
+	exists, err := repo.ContactExistsByEmail(ctx, "alex@example.com")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = repo.RestoreContact(ctx, id)
+	assert.ErrorIs(t, err, ErrContactNotTrashed)
+
+	require.NoError(t, repo.DeleteContact(ctx, id, "admin@example.com"))
+	assert.ErrorIs(t, repo.DeleteContact(ctx, id, "admin@example.com"), ErrContactNotFound)
+
+	// Trashed contacts are hidden from everything except the trash.
+	_, err = repo.GetContactByID(ctx, id)
+	assert.ErrorIs(t, err, ErrContactNotFound)
+	exists, err = repo.ContactExistsByEmail(ctx, "alex@example.com")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	page, err := repo.GetContacts(ctx, ContactFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Contacts)
+	page, err = repo.GetContacts(ctx, ContactFilter{Trashed: true, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Contacts, 1)
+	assert.Equal(t, "admin@example.com", page.Contacts[0].DeletedBy)
+
+	restored, err := repo.RestoreContact(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = repo.GetContactByID(ctx, id)
+	assert.NoError(t, err)
+
+	_, err = repo.RestoreContact(ctx, primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, ErrContactNotFound)
+}
+
+func TestMongoTrashedContactsCannotChange(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoContactRepository(newTestDatabase(t))
+
+	contact := &Contact{Name: "Alex", Email: "alex@example.com"}
+	require.NoError(t, repo.CreateContact(ctx, contact))
+	id := contact.ID.Hex()
+	require.NoError(t, repo.DeleteContact(ctx, id, "admin@example.com"))
+
+	name := "Alexandra"
+	_, err := repo.UpdateContact(ctx, id, ContactUpdate{Name: &name, ExpectedVersion: 1})
+	assert.ErrorIs(t, err, ErrContactNotFound)
+
+	_, err = repo.UpdateContactStatus(ctx, id, StatusChange{
+		From:      ContactStatusNew,
+		To:        ContactStatusRead,
+		ChangedBy: "admin@example.com",
+		ChangedAt: time.Now(),
+	})
+	assert.ErrorIs(t, err, ErrContactNotFound)
+
+	_, err = repo.AddContactReply(ctx, id, ContactReply{Body: "Thanks!", SentAt: time.Now()}, nil)
+	assert.ErrorIs(t, err, ErrContactNotFound)
+
+	_, err = repo.AddContactTags(ctx, id, []string{"vip"})
+	assert.ErrorIs(t, err, ErrContactNotFound)
+	_, err = repo.RemoveContactTag(ctx, id, "vip")
+	assert.ErrorIs(t, err, ErrContactNotFound)
+}
+
+func TestMongoDeleteTrashedContacts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoContactRepository(newTestDatabase(t))
+
+	var ids []primitive.ObjectID
+	for _, email := range []string{"old@example.com", "new@example.com", "live@example.com"} {
+		contact := &Contact{Name: "Alex", Email: email}
+		require.NoError(t, repo.CreateContact(ctx, contact))
+		ids = append(ids, contact.ID)
+	}
+	require.NoError(t, repo.DeleteContact(ctx, ids[0].Hex(), "admin@example.com"))
+	cutoff := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, repo.DeleteContact(ctx, ids[1].Hex(), "admin@example.com"))
+
+	trashed, err := repo.GetContactsTrashedBefore(ctx, cutoff, 10)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, ids[0], trashed[0].ID)
+
+	// Only contacts still trashed before the cutoff are removed, along with
+	// what removeRelated deletes for them.
+	var related []primitive.ObjectID
+	deleted, err := repo.DeleteTrashedContacts(ctx, ids, cutoff, func(_ context.Context, ids []primitive.ObjectID) error {
+		related = ids
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, ids[0], deleted[0].ID)
+	assert.Equal(t, []primitive.ObjectID{ids[0]}, related)
+
+	page, err := repo.GetContacts(ctx, ContactFilter{Trashed: true, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Contacts, 1)
+	assert.Equal(t, ids[1], page.Contacts[0].ID)
+	_, err = repo.GetContactByID(ctx, ids[2].Hex())
+	assert.NoError(t, err)
+}
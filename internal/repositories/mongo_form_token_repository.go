@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFormTokenRepository keeps one document per used nonce until the
+// token it came from would have expired anyway.
+type MongoFormTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoFormTokenRepository(db *mongo.Database) *MongoFormTokenRepository {
+	return &MongoFormTokenRepository{
+		collection: db.Collection("used_form_tokens"),
+	}
+}
+
+// EnsureIndexes forgets nonces once their tokens have expired.
+func (r *MongoFormTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoFormTokenRepository) UseFormToken(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	// The nonce is the _id, so a second use fails on the unique index.
+	_, err := r.collection.InsertOne(ctx, bson.M{"_id": nonce, "expires_at": expiresAt})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
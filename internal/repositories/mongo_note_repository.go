@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoNoteRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoNoteRepository(db *mongo.Database) *MongoNoteRepository {
+	return &MongoNoteRepository{
+		collection: db.Collection("contact_notes"),
+	}
+}
+
+func (r *MongoNoteRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "contact_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func (r *MongoNoteRepository) CreateNote(ctx context.Context, note *Note) error {
+	note.ID = primitive.NewObjectID()
+	note.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, note)
+	return err
+}
+
+func (r *MongoNoteRepository) GetNoteByID(ctx context.Context, id primitive.ObjectID) (Note, error) {
+	var note Note
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&note); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Note{}, ErrNoteNotFound
+		}
+		return Note{}, err
+	}
+	return note, nil
+}
+
+func (r *MongoNoteRepository) GetNotesByContactID(ctx context.Context, contactID primitive.ObjectID) ([]Note, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"contact_id": contactID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notes := []Note{}
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *MongoNoteRepository) DeleteNotesByContactIDs(ctx context.Context, contactIDs []primitive.ObjectID) (int64, error) {
+	if len(contactIDs) == 0 {
+		return 0, nil
+	}
+	result, err := r.collection.DeleteMany(ctx, bson.M{"contact_id": bson.M{"$in": contactIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPersonRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPersonRepository(db *mongo.Database) *MongoPersonRepository {
+	return &MongoPersonRepository{
+		collection: db.Collection("people"),
+	}
+}
+
+func (r *MongoPersonRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "emails", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "last_seen_at", Value: -1}},
+		},
+	})
+	return err
+}
+
+func (r *MongoPersonRepository) RecordSubmission(ctx context.Context, email, name string, seenAt time.Time) (Person, error) {
+	// $in rather than equality so the filter value is not copied into a new
+	// document on upsert.
+	filter := bson.M{"emails": bson.M{"$in": bson.A{email}}}
+	update := bson.M{
+		"$setOnInsert": bson.M{"email": email, "emails": bson.A{email}},
+		"$set":         bson.M{"name": name},
+		"$inc":         bson.M{"message_count": 1},
+		"$min":         bson.M{"first_seen_at": seenAt},
+		"$max":         bson.M{"last_seen_at": seenAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var person Person
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&person)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent submission created the person first; retry as an
+		// update.
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&person)
+	}
+	return person, err
+}
+
+func (r *MongoPersonRepository) GetPersonByID(ctx context.Context, id string) (Person, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Person{}, err
+	}
+	var person Person
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&person); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Person{}, ErrPersonNotFound
+		}
+		return Person{}, err
+	}
+	return person, nil
+}
+
+func (r *MongoPersonRepository) RemoveSubmissions(ctx context.Context, id primitive.ObjectID, n int) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"message_count": -n}})
+	return err
+}
+
+func (r *MongoPersonRepository) GetPersonByEmail(ctx context.Context, email string) (Person, error) {
+	var person Person
+	if err := r.collection.FindOne(ctx, bson.M{"emails": email}).Decode(&person); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Person{}, ErrPersonNotFound
+		}
+		return Person{}, err
+	}
+	return person, nil
+}
+
+func (r *MongoPersonRepository) RemovePersonEmail(ctx context.Context, email string) error {
+	person, err := r.GetPersonByEmail(ctx, email)
+	if err == ErrPersonNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0, len(person.Emails))
+	for _, e := range person.Emails {
+		if e != email {
+			remaining = append(remaining, e)
+		}
+	}
+	if len(remaining) == 0 {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": person.ID})
+		return err
+	}
+
+	primary := person.Email
+	if primary == email {
+		primary = remaining[0]
+	}
+	_, err = r.collection.UpdateOne(ctx,
+		bson.M{"_id": person.ID},
+		bson.M{"$set": bson.M{"email": primary, "emails": remaining}},
+	)
+	return err
+}
+
+func (r *MongoPersonRepository) GetPeople(ctx context.Context, filter PersonFilter) ([]Person, int64, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		query["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"emails": pattern}}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_seen_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(filter.Offset)).
+		SetLimit(int64(filter.Limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	people := []Person{}
+	if err := cursor.All(ctx, &people); err != nil {
+		return nil, 0, err
+	}
+	return people, total, nil
+}
+
+func (r *MongoPersonRepository) MergePeople(ctx context.Context, targetID, sourceID string, moveContacts func(ctx context.Context, sourceID, targetID primitive.ObjectID) error) (Person, error) {
+	sourceObjID, err := primitive.ObjectIDFromHex(sourceID)
+	if err != nil {
+		return Person{}, err
+	}
+	targetObjID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		return Person{}, err
+	}
+
+	// The source is deleted before its addresses move so the unique index
+	// on emails allows them on the target. The writes and the contact move
+	// happen in one transaction, so a failed merge loses no addresses or
+	// contacts and a concurrent submission from one of them conflicts and
+	// retries instead of creating a new person in between.
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return Person{}, err
+	}
+	defer session.EndSession(ctx)
+
+	merged, err := session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// Read the source as it is deleted so addresses it gained since the
+		// caller looked it up move too.
+		var source Person
+		if err := r.collection.FindOneAndDelete(sc, bson.M{"_id": sourceObjID}).Decode(&source); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrPersonNotFound
+			}
+			return nil, err
+		}
+		if err := moveContacts(sc, sourceObjID, targetObjID); err != nil {
+			return nil, err
+		}
+
+		update := bson.M{
+			"$addToSet": bson.M{"emails": bson.M{"$each": source.Emails}},
+			"$inc":      bson.M{"message_count": source.MessageCount},
+			"$min":      bson.M{"first_seen_at": source.FirstSeenAt},
+			"$max":      bson.M{"last_seen_at": source.LastSeenAt},
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+		var merged Person
+		if err := r.collection.FindOneAndUpdate(sc, bson.M{"_id": targetObjID}, update, opts).Decode(&merged); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrPersonNotFound
+			}
+			return nil, err
+		}
+		return merged, nil
+	})
+	if err != nil {
+		return Person{}, err
+	}
+	return merged.(Person), nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDatabase connects to the replica set at MONGODB_TEST_URI and
+// returns a throwaway database, skipping the test when it isn't set.
+func newTestDatabase(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("Skipping MongoDB test - MONGODB_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	db := client.Database(fmt.Sprintf("chanterelle_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
+
+// moveContactsWith relinks contacts through the contact repository, as the
+// contact service does.
+func moveContactsWith(contacts *MongoContactRepository) func(context.Context, primitive.ObjectID, primitive.ObjectID) error {
+	return func(ctx context.Context, sourceID, targetID primitive.ObjectID) error {
+		_, err := contacts.MoveContactsToPerson(ctx, sourceID, targetID)
+		return err
+	}
+}
+
+func TestMongoMergePeople(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewMongoPersonRepository(db)
+	contacts := NewMongoContactRepository(db)
+	require.NoError(t, repo.EnsureIndexes(ctx))
+
+	seen := time.Now().UTC().Truncate(time.Millisecond)
+	target, err := repo.RecordSubmission(ctx, "alex@example.com", "Alex", seen)
+	require.NoError(t, err)
+	source, err := repo.RecordSubmission(ctx, "alex.smith@example.com", "Alex Smith", seen.Add(-time.Hour))
+	require.NoError(t, err)
+
+	contact := &Contact{Name: "Alex Smith", Email: "alex.smith@example.com", PersonID: &source.ID}
+	require.NoError(t, contacts.CreateContact(ctx, contact))
+
+	merged, err := repo.MergePeople(ctx, target.ID.Hex(), source.ID.Hex(), moveContactsWith(contacts))
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, merged.ID)
+	assert.ElementsMatch(t, []string{"alex@example.com", "alex.smith@example.com"}, merged.Emails)
+	assert.Equal(t, 2, merged.MessageCount)
+	assert.True(t, merged.FirstSeenAt.Equal(seen.Add(-time.Hour)))
+
+	_, err = repo.GetPersonByID(ctx, source.ID.Hex())
+	assert.ErrorIs(t, err, ErrPersonNotFound)
+	moved, err := contacts.GetContactByID(ctx, contact.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, *moved.PersonID)
+
+	// Later submissions from the merged address land on the target.
+	again, err := repo.RecordSubmission(ctx, "alex.smith@example.com", "Alex Smith", seen)
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, again.ID)
+}
+
+func TestMongoMergePeopleFailureKeepsSource(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewMongoPersonRepository(db)
+	contacts := NewMongoContactRepository(db)
+	require.NoError(t, repo.EnsureIndexes(ctx))
+
+	source, err := repo.RecordSubmission(ctx, "sam@example.com", "Sam", time.Now())
+	require.NoError(t, err)
+	contact := &Contact{Name: "Sam", Email: "sam@example.com", PersonID: &source.ID}
+	require.NoError(t, contacts.CreateContact(ctx, contact))
+
+	// Merging into a missing target rolls back deleting the source and
+	// moving its contacts.
+	_, err = repo.MergePeople(ctx, primitive.NewObjectID().Hex(), source.ID.Hex(), moveContactsWith(contacts))
+	assert.ErrorIs(t, err, ErrPersonNotFound)
+
+	stayed, err := contacts.GetContactByID(ctx, contact.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, source.ID, *stayed.PersonID)
+
+	kept, err := repo.GetPersonByID(ctx, source.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, source.ID, kept.ID)
+}
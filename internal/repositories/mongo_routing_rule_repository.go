@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoRoutingRuleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRoutingRuleRepository(db *mongo.Database) *MongoRoutingRuleRepository {
+	return &MongoRoutingRuleRepository{
+		collection: db.Collection("routing_rules"),
+	}
+}
+
+func (r *MongoRoutingRuleRepository) GetRoutingRules(ctx context.Context) ([]RoutingRule, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []RoutingRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *MongoRoutingRuleRepository) GetRoutingRule(ctx context.Context, category ContactCategory) (RoutingRule, error) {
+	var rule RoutingRule
+	if err := r.collection.FindOne(ctx, bson.M{"_id": category}).Decode(&rule); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return RoutingRule{}, ErrRoutingRuleNotFound
+		}
+		return RoutingRule{}, err
+	}
+	return rule, nil
+}
+
+func (r *MongoRoutingRuleRepository) SaveRoutingRule(ctx context.Context, rule RoutingRule) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rule.Category}, rule, opts)
+	return err
+}
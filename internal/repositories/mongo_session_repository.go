@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionRepository(db *mongo.Database) *MongoSessionRepository {
+	return &MongoSessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+func (r *MongoSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "last_seen_at", Value: -1}},
+		},
+		{
+			// Sessions are removed once their refresh token has expired
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// activeSession matches a session that can still be used at now.
+func activeSession(id primitive.ObjectID, now time.Time) bson.M {
+	return bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+}
+
+func (r *MongoSessionRepository) CreateSession(ctx context.Context, session *Session) error {
+	session.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionRepository) TouchSession(ctx context.Context, id primitive.ObjectID, ip string, now time.Time) (Session, error) {
+	var session Session
+	err := r.collection.FindOneAndUpdate(ctx,
+		activeSession(id, now),
+		bson.M{"$set": bson.M{"last_seen_at": now, "ip": ip}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Session{}, ErrSessionNotFound
+		}
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *MongoSessionRepository) GetActiveSession(ctx context.Context, id primitive.ObjectID, now time.Time) (Session, error) {
+	var session Session
+	if err := r.collection.FindOne(ctx, activeSession(id, now)).Decode(&session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Session{}, ErrSessionNotFound
+		}
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func (r *MongoSessionRepository) UpdateRefreshTokens(ctx context.Context, session Session, previousHash string, now time.Time) (Session, error) {
+	filter := activeSession(session.ID, now)
+	filter["refresh_hash"] = previousHash
+
+	var updated Session
+	err := r.collection.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"refresh_hash":         session.RefreshHash,
+			"spare_refresh_hashes": session.SpareRefreshHashes,
+			"used_refresh_tokens":  session.UsedRefreshTokens,
+			"last_seen_at":         session.LastSeenAt,
+			"expires_at":           session.ExpiresAt,
+			"ip":                   session.IP,
+			"user_agent":           session.UserAgent,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Session{}, ErrSessionNotFound
+		}
+		return Session{}, err
+	}
+	return updated, nil
+}
+
+func (r *MongoSessionRepository) RevokeSession(ctx context.Context, id primitive.ObjectID, email, reason string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "email": email, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *MongoSessionRepository) RevokeSessions(ctx context.Context, email, reason string) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"email": email, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoSessionRepository) GetActiveSessions(ctx context.Context, email string, now time.Time) ([]Session, error) {
+	filter := bson.M{
+		"email":      email,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *MongoSessionRepository) GetSessionsByEmail(ctx context.Context, email string) ([]Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"email": emailQuery(email)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *MongoSessionRepository) DeleteSessionsByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"email": emailQuery(email)})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
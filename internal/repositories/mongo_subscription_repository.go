@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoSubscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSubscriptionRepository(db *mongo.Database) *MongoSubscriptionRepository {
+	return &MongoSubscriptionRepository{
+		collection: db.Collection("subscriptions"),
+	}
+}
+
+// EnsureIndexes removes pending subscriptions once they expire. Confirming a
+// subscription clears expires_at, so confirmed ones are kept.
+func (r *MongoSubscriptionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}},
+		},
+	})
+	return err
+}
+
+func (r *MongoSubscriptionRepository) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	subscription.ID = primitive.NewObjectID()
+	subscription.CreatedAt = time.Now()
+	if subscription.Status == "" {
+		subscription.Status = SubscriptionPending
+	}
+	_, err := r.collection.InsertOne(ctx, subscription)
+	return err
+}
+
+func (r *MongoSubscriptionRepository) GetSubscriptionByID(ctx context.Context, id primitive.ObjectID) (Subscription, error) {
+	var subscription Subscription
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&subscription); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Subscription{}, ErrSubscriptionNotFound
+		}
+		return Subscription{}, err
+	}
+	return subscription, nil
+}
+
+func (r *MongoSubscriptionRepository) IsSubscribed(ctx context.Context, email string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx,
+		bson.M{"email": email, "status": SubscriptionConfirmed},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *MongoSubscriptionRepository) ConfirmSubscription(ctx context.Context, id primitive.ObjectID, now time.Time) (Subscription, bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"status":     SubscriptionPending,
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": SubscriptionConfirmed, "confirmed_at": now},
+		"$unset": bson.M{"expires_at": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var subscription Subscription
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&subscription)
+	if err == nil {
+		return subscription, true, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return Subscription{}, false, err
+	}
+
+	// Nothing to confirm: either it already was, or it has expired.
+	existing, err := r.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return Subscription{}, false, err
+	}
+	if existing.Status == SubscriptionConfirmed {
+		return existing, false, nil
+	}
+	return Subscription{}, false, ErrSubscriptionExpired
+}
+
+func (r *MongoSubscriptionRepository) GetSubscriptionsByEmail(ctx context.Context, email string) ([]Subscription, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"email": email}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []Subscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *MongoSubscriptionRepository) DeleteSubscriptionsByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"email": email})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
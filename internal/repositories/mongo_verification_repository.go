@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,20 +10,53 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// verificationFailureRetention is how long failed attempts stay in the
+// audit log.
+const verificationFailureRetention = 30 * 24 * time.Hour
+
 type MongoVerificationRepository struct {
 	collection *mongo.Collection
+	failures   *mongo.Collection
+	lockouts   *mongo.Collection
 }
 
 func NewMongoVerificationRepository(db *mongo.Database) *MongoVerificationRepository {
 	return &MongoVerificationRepository{
 		collection: db.Collection("verification_codes"),
+		failures:   db.Collection("verification_failures"),
+		lockouts:   db.Collection("verification_lockouts"),
 	}
 }
 
-func (r *MongoVerificationRepository) CreateVerificationCode(ctx context.Context, email string, code string, expiry time.Duration) error {
+func (r *MongoVerificationRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.failures.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "ip", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(verificationFailureRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+func (r *MongoVerificationRepository) CreateVerificationCode(ctx context.Context, email string, codeHash string, expiry time.Duration) error {
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"email": email}); err != nil {
+		return err
+	}
+
 	verificationCode := VerificationCode{
 		ID:        primitive.NewObjectID().Hex(),
-		Code:      code,
+		CodeHash:  codeHash,
 		Email:     email,
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(expiry),
@@ -33,29 +65,186 @@ func (r *MongoVerificationRepository) CreateVerificationCode(ctx context.Context
 	return err
 }
 
-func (r *MongoVerificationRepository) GetCodeByEmail(ctx context.Context, email string) (*VerificationCode, error) {
-	// Find the latest verification code for the email
-	filter := bson.D{{Key: "email", Value: email}}
-	sort := bson.D{{Key: "created_at", Value: -1}}
+func (r *MongoVerificationRepository) ConsumeCode(ctx context.Context, email, codeHash string, now time.Time, maxAttempts int) (*VerificationCode, error) {
+	// Reserve the guess first so concurrent guesses each use up one. A
+	// right guess is counted too, but deletes the code just after.
+	filter := bson.M{
+		"email":      email,
+		"expires_at": bson.M{"$gt": now},
+		"attempts":   bson.M{"$lt": maxAttempts},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetReturnDocument(options.After)
 
 	var verificationCode VerificationCode
-	if err := r.collection.FindOne(ctx, filter, options.FindOne().SetSort(sort)).Decode(&verificationCode); err != nil {
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"attempts": 1}}, opts).Decode(&verificationCode)
+	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("verification code not found")
+			return nil, ErrVerificationCodeNotFound
 		}
 		return nil, err
 	}
+	if verificationCode.CodeHash != codeHash {
+		return &verificationCode, ErrVerificationCodeMismatch
+	}
 
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": verificationCode.ID})
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedCount == 0 {
+		// Used by a concurrent request with the same code.
+		return nil, ErrVerificationCodeNotFound
+	}
 	return &verificationCode, nil
 }
 
-func (r *MongoVerificationRepository) DeleteCodeByEmail(ctx context.Context, email string) error {
-	filter := bson.D{{Key: "email", Value: email}}
-	_, err := r.collection.DeleteOne(ctx, filter)
+func (r *MongoVerificationRepository) DeleteExpiredCodes(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": time.Now()}})
 	return err
 }
 
-func (r *MongoVerificationRepository) DeleteExpiredCodes(ctx context.Context) error {
-	_, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": time.Now()}})
+func (r *MongoVerificationRepository) GetCodesByEmail(ctx context.Context, email string) ([]VerificationCode, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"email": emailQuery(email)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	codes := []VerificationCode{}
+	if err := cursor.All(ctx, &codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *MongoVerificationRepository) DeleteCodesByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"email": emailQuery(email)})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoVerificationRepository) DeleteCodeByID(ctx context.Context, codeID string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": codeID})
+	return err
+}
+
+func (r *MongoVerificationRepository) RecordFailure(ctx context.Context, failure *VerificationFailure) error {
+	failure.ID = primitive.NewObjectID()
+	if failure.CreatedAt.IsZero() {
+		failure.CreatedAt = time.Now()
+	}
+	_, err := r.failures.InsertOne(ctx, failure)
+	return err
+}
+
+func (r *MongoVerificationRepository) GetFailures(ctx context.Context, filter VerificationFailureFilter) ([]VerificationFailure, error) {
+	query := bson.M{}
+	if filter.Email != "" {
+		query["email"] = emailQuery(filter.Email)
+	}
+	if filter.IP != "" {
+		query["ip"] = filter.IP
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := r.failures.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	failures := []VerificationFailure{}
+	if err := cursor.All(ctx, &failures); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+func (r *MongoVerificationRepository) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	return r.failures.CountDocuments(ctx, bson.M{"ip": ip, "created_at": bson.M{"$gte": since}})
+}
+
+func (r *MongoVerificationRepository) CountFailuresByEmail(ctx context.Context, email string, since time.Time) (int64, error) {
+	return r.failures.CountDocuments(ctx, bson.M{"email": email, "created_at": bson.M{"$gte": since}})
+}
+
+func (r *MongoVerificationRepository) DeleteFailuresByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.failures.DeleteMany(ctx, bson.M{"email": emailQuery(email)})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoVerificationRepository) GetLockout(ctx context.Context, email string) (*VerificationLockout, error) {
+	var lockout VerificationLockout
+	if err := r.lockouts.FindOne(ctx, bson.M{"_id": email}).Decode(&lockout); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+func (r *MongoVerificationRepository) Lockout(ctx context.Context, email string, now time.Time, base, max time.Duration) (VerificationLockout, error) {
+	var lockout VerificationLockout
+	err := r.lockouts.FindOneAndUpdate(ctx,
+		bson.M{"_id": email},
+		bson.M{"$inc": bson.M{"level": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&lockout)
+	if err != nil {
+		return VerificationLockout{}, err
+	}
+
+	duration := base
+	for i := 1; i < lockout.Level && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		duration = max
+	}
+	lockout.LockedUntil = now.Add(duration)
+
+	_, err = r.lockouts.UpdateOne(ctx,
+		bson.M{"_id": email},
+		bson.M{"$set": bson.M{"locked_until": lockout.LockedUntil}},
+	)
+	return lockout, err
+}
+
+func (r *MongoVerificationRepository) ClearLockout(ctx context.Context, email string) error {
+	_, err := r.lockouts.DeleteOne(ctx, bson.M{"_id": email})
 	return err
 }
+
+func (r *MongoVerificationRepository) GetLockoutsByEmail(ctx context.Context, email string) ([]VerificationLockout, error) {
+	cursor, err := r.lockouts.Find(ctx, bson.M{"_id": emailQuery(email)})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	lockouts := []VerificationLockout{}
+	if err := cursor.All(ctx, &lockouts); err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+func (r *MongoVerificationRepository) DeleteLockoutsByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := r.lockouts.DeleteMany(ctx, bson.M{"_id": emailQuery(email)})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
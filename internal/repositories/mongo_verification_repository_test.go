@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoConsumeCodeReservesAttempts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMongoVerificationRepository(newTestDatabase(t))
+	require.NoError(t, repo.EnsureIndexes(ctx))
+	require.NoError(t, repo.CreateVerificationCode(ctx, "admin@example.com", "right", time.Hour))
+
+	const guesses = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := map[error]int{}
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ConsumeCode(ctx, "admin@example.com", "wrong", time.Now(), 3)
+			mu.Lock()
+			counts[err]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, counts[ErrVerificationCodeMismatch])
+	assert.Equal(t, guesses-3, counts[ErrVerificationCodeNotFound])
+
+	// Out of attempts, even the right code is refused.
+	_, err := repo.ConsumeCode(ctx, "admin@example.com", "right", time.Now(), 3)
+	assert.ErrorIs(t, err, ErrVerificationCodeNotFound)
+
+	require.NoError(t, repo.CreateVerificationCode(ctx, "admin@example.com", "right", time.Hour))
+	code, err := repo.ConsumeCode(ctx, "admin@example.com", "right", time.Now(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", code.Email)
+	_, err = repo.ConsumeCode(ctx, "admin@example.com", "right", time.Now(), 3)
+	assert.ErrorIs(t, err, ErrVerificationCodeNotFound)
+}
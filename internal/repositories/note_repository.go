@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrNoteNotFound = errors.New("note not found")
+
+// Note is an internal admin note on a contact. Notes with a ParentID are
+// replies in that note's thread.
+type Note struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	ContactID primitive.ObjectID  `bson:"contact_id"`
+	ParentID  *primitive.ObjectID `bson:"parent_id,omitempty"`
+	Author    string              `bson:"author"`
+	Body      string              `bson:"body"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+type NoteRepository interface {
+	// CreateNote stores a note, filling in its ID and creation time.
+	CreateNote(ctx context.Context, note *Note) error
+	GetNoteByID(ctx context.Context, id primitive.ObjectID) (Note, error)
+	// GetNotesByContactID returns every note on a contact, oldest first.
+	GetNotesByContactID(ctx context.Context, contactID primitive.ObjectID) ([]Note, error)
+	// DeleteNotesByContactIDs removes every note on the contacts.
+	DeleteNotesByContactIDs(ctx context.Context, contactIDs []primitive.ObjectID) (int64, error)
+}
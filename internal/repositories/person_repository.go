@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrPersonNotFound = errors.New("person not found")
+
+// Person is everyone who has written in from the same address, keyed by
+// normalized email. Merging people keeps every address the person used in
+// Emails so later submissions from any of them land on the same person.
+type Person struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Email  string             `bson:"email"`
+	Emails []string           `bson:"emails"`
+	Name   string             `bson:"name"`
+	// MessageCount is the number of submissions received from the person.
+	MessageCount int       `bson:"message_count"`
+	FirstSeenAt  time.Time `bson:"first_seen_at"`
+	LastSeenAt   time.Time `bson:"last_seen_at"`
+}
+
+type PersonFilter struct {
+	// Query matches a substring of the name or any email, ignoring case.
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type PersonRepository interface {
+	// RecordSubmission finds the person using the normalized email, creating
+	// them if needed, and counts one more submission seen at seenAt.
+	RecordSubmission(ctx context.Context, email, name string, seenAt time.Time) (Person, error)
+	GetPersonByID(ctx context.Context, id string) (Person, error)
+	// RemoveSubmissions stops counting n submissions from the person, for
+	// contacts that moved to someone else or were removed.
+	RemoveSubmissions(ctx context.Context, id primitive.ObjectID, n int) error
+	// GetPersonByEmail finds the person using a normalized email.
+	GetPersonByEmail(ctx context.Context, email string) (Person, error)
+	// RemovePersonEmail forgets a normalized email. A person left without
+	// any email is deleted.
+	RemovePersonEmail(ctx context.Context, email string) error
+	GetPeople(ctx context.Context, filter PersonFilter) ([]Person, int64, error)
+	// MergePeople folds source into target, keeping every email address of
+	// both, and deletes source. moveContacts is called with the same ctx to
+	// relink source's contacts to target. Either all of it happens or none
+	// of it.
+	MergePeople(ctx context.Context, targetID, sourceID string, moveContacts func(ctx context.Context, sourceID, targetID primitive.ObjectID) error) (Person, error)
+}
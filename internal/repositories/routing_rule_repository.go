@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrRoutingRuleNotFound = errors.New("routing rule not found")
+
+// RoutingRule decides what happens after a contact in a category is
+// received.
+type RoutingRule struct {
+	Category ContactCategory `bson:"_id"`
+	// NotifyEmails are the addresses told about each new submission.
+	NotifyEmails []string `bson:"notify_emails"`
+	// Subscribe adds the sender to the Mailchimp list.
+	Subscribe bool `bson:"subscribe"`
+	// AutoReplyTemplateID is the EmailJS template acknowledging the sender.
+	// Empty sends no auto-reply.
+	AutoReplyTemplateID string     `bson:"auto_reply_template_id,omitempty"`
+	UpdatedAt           *time.Time `bson:"updated_at,omitempty"`
+	UpdatedBy           string     `bson:"updated_by,omitempty"`
+}
+
+type RoutingRuleRepository interface {
+	GetRoutingRules(ctx context.Context) ([]RoutingRule, error)
+	// GetRoutingRule returns ErrRoutingRuleNotFound if no rule has been saved
+	// for the category.
+	GetRoutingRule(ctx context.Context, category ContactCategory) (RoutingRule, error)
+	// SaveRoutingRule creates or replaces the rule for its category.
+	SaveRoutingRule(ctx context.Context, rule RoutingRule) error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// UsedRefreshToken is a refresh token that was exchanged for new tokens.
+type UsedRefreshToken struct {
+	Hash   string    `bson:"hash"`
+	UsedAt time.Time `bson:"used_at"`
+}
+
+// Session is one signed-in device of an admin. Access tokens name their
+// session, so revoking it signs the device out at once.
+type Session struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email string             `bson:"email" json:"email"`
+	// RefreshHash is the hash of the current refresh token. It changes on
+	// every refresh.
+	RefreshHash string `bson:"refresh_hash" json:"-"`
+	// SpareRefreshHashes are older refresh tokens that were never used and
+	// still work. They are left behind when two tabs refresh at once.
+	SpareRefreshHashes []string `bson:"spare_refresh_hashes,omitempty" json:"-"`
+	// UsedRefreshTokens are the latest exchanged refresh tokens, kept to
+	// tell a replayed token from a mistyped one.
+	UsedRefreshTokens []UsedRefreshToken `bson:"used_refresh_tokens,omitempty" json:"-"`
+	UserAgent         string             `bson:"user_agent" json:"user_agent"`
+	IP                string             `bson:"ip" json:"ip"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	LastSeenAt        time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt         time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt         *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	// RevokedReason says why the session ended, such as logout.
+	RevokedReason string `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"`
+}
+
+type SessionRepository interface {
+	// CreateSession stores a session, filling in its ID.
+	CreateSession(ctx context.Context, session *Session) error
+	// TouchSession records activity on a session that is neither revoked
+	// nor expired at now, and returns ErrSessionNotFound otherwise.
+	TouchSession(ctx context.Context, id primitive.ObjectID, ip string, now time.Time) (Session, error)
+	// GetActiveSession returns a session that is neither revoked nor
+	// expired at now, or ErrSessionNotFound.
+	GetActiveSession(ctx context.Context, id primitive.ObjectID, now time.Time) (Session, error)
+	// UpdateRefreshTokens saves session's refresh tokens, expiry, IP, user
+	// agent and last use if it is still active at now and its refresh hash
+	// is still previousHash, so only one of two concurrent refreshes of the
+	// same state applies. It returns ErrSessionNotFound otherwise.
+	UpdateRefreshTokens(ctx context.Context, session Session, previousHash string, now time.Time) (Session, error)
+	// RevokeSession ends one of email's sessions.
+	RevokeSession(ctx context.Context, id primitive.ObjectID, email, reason string) error
+	// RevokeSessions ends every active session of email.
+	RevokeSessions(ctx context.Context, email, reason string) (int64, error)
+	// GetActiveSessions lists email's sessions, most recently used first.
+	GetActiveSessions(ctx context.Context, email string, now time.Time) ([]Session, error)
+	// GetSessionsByEmail lists every stored session of the email, ignoring
+	// case, including revoked ones.
+	GetSessionsByEmail(ctx context.Context, email string) ([]Session, error)
+	// DeleteSessionsByEmail removes every session of the email, ignoring
+	// case.
+	DeleteSessionsByEmail(ctx context.Context, email string) (int64, error)
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrSubscriptionExpired  = errors.New("subscription confirmation has expired")
+)
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionPending   SubscriptionStatus = "pending"
+	SubscriptionConfirmed SubscriptionStatus = "confirmed"
+)
+
+// Subscription is a mailing list signup awaiting or having received
+// confirmation by email. Confirmed subscriptions form the local subscriber
+// list.
+type Subscription struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+	// Email is normalized.
+	Email     string             `bson:"email"`
+	Name      string             `bson:"name"`
+	ContactID primitive.ObjectID `bson:"contact_id"`
+	Status    SubscriptionStatus `bson:"status"`
+	CreatedAt time.Time          `bson:"created_at"`
+	// ExpiresAt is when an unconfirmed subscription lapses. Pending
+	// subscriptions are removed soon after.
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty"`
+	ConfirmedAt *time.Time `bson:"confirmed_at,omitempty"`
+}
+
+type SubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, subscription *Subscription) error
+	GetSubscriptionByID(ctx context.Context, id primitive.ObjectID) (Subscription, error)
+	GetSubscriptionsByEmail(ctx context.Context, email string) ([]Subscription, error)
+	DeleteSubscriptionsByEmail(ctx context.Context, email string) (int64, error)
+	// IsSubscribed reports whether the email has a confirmed subscription.
+	IsSubscribed(ctx context.Context, email string) (bool, error)
+	// ConfirmSubscription confirms a pending subscription that has not
+	// expired at now and reports whether this call confirmed it. Confirming
+	// an already confirmed subscription returns it unchanged.
+	ConfirmSubscription(ctx context.Context, id primitive.ObjectID, now time.Time) (Subscription, bool, error)
+}
@@ -2,20 +2,92 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrVerificationCodeNotFound = errors.New("verification code not found")
+	ErrVerificationCodeMismatch = errors.New("verification code does not match")
 )
 
+// VerificationCode is an outstanding sign-in code. Only a keyed hash of the
+// code is stored, so reading the database doesn't reveal usable codes.
 type VerificationCode struct {
 	ID        string    `bson:"_id,omitempty"`
-	Code      string    `bson:"code"`
+	CodeHash  string    `bson:"code_hash"`
 	Email     string    `bson:"email"`
 	CreatedAt time.Time `bson:"created_at"`
 	ExpiresAt time.Time `bson:"expires_at"`
+	// Attempts counts guesses at this code, right or wrong. Each guess is
+	// counted before it is checked, so concurrent guesses can't exceed the
+	// limit; a right guess deletes the code straight after.
+	Attempts int `bson:"attempts"`
+}
+
+// VerificationFailure is one failed attempt to sign in with a code, kept
+// for the admin audit view.
+type VerificationFailure struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email     string             `bson:"email" json:"email"`
+	IP        string             `bson:"ip" json:"ip"`
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type VerificationFailureFilter struct {
+	Email string
+	IP    string
+	Limit int
+}
+
+// VerificationLockout stops an email from signing in until LockedUntil.
+// Level counts lockouts in a row and sets how long the next one lasts.
+type VerificationLockout struct {
+	Email       string    `bson:"_id" json:"email"`
+	Level       int       `bson:"level" json:"level"`
+	LockedUntil time.Time `bson:"locked_until" json:"locked_until"`
 }
 
 type VerificationRepository interface {
-	CreateVerificationCode(ctx context.Context, email, code string, expiry time.Duration) error
-	GetCodeByEmail(ctx context.Context, email string) (*VerificationCode, error)
-	DeleteCodeByEmail(ctx context.Context, email string) error
+	// CreateVerificationCode stores a new code for email and invalidates
+	// every code issued to it before.
+	CreateVerificationCode(ctx context.Context, email, codeHash string, expiry time.Duration) error
+	// ConsumeCode reserves one of the maxAttempts guesses at email's latest
+	// code that has not expired at now by incrementing its attempts, then
+	// deletes and returns it if codeHash matches, so a code can only be used
+	// once. It returns ErrVerificationCodeNotFound if there is no such code,
+	// and the code with its updated count and ErrVerificationCodeMismatch if
+	// the hash is wrong.
+	ConsumeCode(ctx context.Context, email, codeHash string, now time.Time, maxAttempts int) (*VerificationCode, error)
 	DeleteExpiredCodes(ctx context.Context) error
+	// GetCodesByEmail lists every stored code for the email, ignoring case.
+	GetCodesByEmail(ctx context.Context, email string) ([]VerificationCode, error)
+	// DeleteCodesByEmail removes every code for the email, ignoring case.
+	DeleteCodesByEmail(ctx context.Context, email string) (int64, error)
+	DeleteCodeByID(ctx context.Context, codeID string) error
+
+	// RecordFailure stores a failed attempt for the audit view.
+	RecordFailure(ctx context.Context, failure *VerificationFailure) error
+	GetFailures(ctx context.Context, filter VerificationFailureFilter) ([]VerificationFailure, error)
+	CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int64, error)
+	CountFailuresByEmail(ctx context.Context, email string, since time.Time) (int64, error)
+	// DeleteFailuresByEmail removes every failure recorded for the email,
+	// ignoring case.
+	DeleteFailuresByEmail(ctx context.Context, email string) (int64, error)
+
+	// GetLockout returns nil if the email has never been locked out.
+	GetLockout(ctx context.Context, email string) (*VerificationLockout, error)
+	// Lockout locks email out for base doubled for every lockout in a row
+	// before this one, up to max.
+	Lockout(ctx context.Context, email string, now time.Time, base, max time.Duration) (VerificationLockout, error)
+	// ClearLockout resets the lockout level after a successful sign-in.
+	ClearLockout(ctx context.Context, email string) error
+	// GetLockoutsByEmail lists the lockouts for the email, ignoring case.
+	GetLockoutsByEmail(ctx context.Context, email string) ([]VerificationLockout, error)
+	// DeleteLockoutsByEmail removes the lockouts for the email, ignoring
+	// case.
+	DeleteLockoutsByEmail(ctx context.Context, email string) (int64, error)
 }
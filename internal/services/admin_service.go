@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+var (
+	ErrInvalidAdminRole = errors.New("role must be owner, editor or viewer")
+	// ErrProtectedAdmin is returned when removing the owner named by
+	// ADMIN_EMAIL, who would be restored at the next start, or the last
+	// remaining owner.
+	ErrProtectedAdmin = errors.New("this admin can't be removed")
+)
+
+// AdminService manages who can sign in to the admin area and with which
+// role.
+type AdminService struct {
+	repository          repositories.AdminRepository
+	sessions            repositories.SessionRepository
+	notificationService *NotificationService
+	ownerEmail          string
+	frontendURL         string
+}
+
+func NewAdminService(repository repositories.AdminRepository, sessions repositories.SessionRepository, notificationService *NotificationService, cfg *config.Config) *AdminService {
+	return &AdminService{
+		repository:          repository,
+		sessions:            sessions,
+		notificationService: notificationService,
+		ownerEmail:          normalizeEmail(cfg.AdminEmail),
+		frontendURL:         strings.TrimRight(cfg.FrontendURL, "/"),
+	}
+}
+
+// SeedOwner makes ADMIN_EMAIL an owner so there is always someone who can
+// sign in and invite the others.
+func (s *AdminService) SeedOwner(ctx context.Context) error {
+	return s.repository.EnsureOwner(ctx, s.ownerEmail)
+}
+
+// GetAdmin returns repositories.ErrAdminNotFound for anyone who isn't an
+// admin.
+func (s *AdminService) GetAdmin(ctx context.Context, email string) (repositories.Admin, error) {
+	return s.repository.GetAdmin(ctx, normalizeEmail(email))
+}
+
+func (s *AdminService) GetAdmins(ctx context.Context) ([]repositories.Admin, error) {
+	return s.repository.GetAdmins(ctx)
+}
+
+// InviteAdmin gives email access with role and tells them by email.
+func (s *AdminService) InviteAdmin(ctx context.Context, email string, role repositories.AdminRole, invitedBy string) (repositories.Admin, error) {
+	if !role.IsValid() {
+		return repositories.Admin{}, ErrInvalidAdminRole
+	}
+
+	admin := repositories.Admin{
+		Email:     normalizeEmail(email),
+		Role:      role,
+		InvitedBy: invitedBy,
+	}
+	if err := s.repository.CreateAdmin(ctx, &admin); err != nil {
+		return repositories.Admin{}, err
+	}
+
+	if err := s.notificationService.SendAdminInvite(admin.Email, string(role), invitedBy, s.frontendURL); err != nil {
+		return admin, err
+	}
+	return admin, nil
+}
+
+// RemoveAdmin revokes email's access and signs them out everywhere.
+func (s *AdminService) RemoveAdmin(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if email == s.ownerEmail {
+		return ErrProtectedAdmin
+	}
+
+	err := s.repository.DeleteAdmin(ctx, email)
+	if errors.Is(err, repositories.ErrLastOwner) {
+		return ErrProtectedAdmin
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.sessions.RevokeSessions(ctx, email, "admin removed")
+	return err
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAdminRepository is an in-memory AdminRepository for tests.
+type memoryAdminRepository struct {
+	repositories.AdminRepository
+	admins map[string]repositories.Admin
+}
+
+func newMemoryAdminRepository(admins ...repositories.Admin) *memoryAdminRepository {
+	r := &memoryAdminRepository{admins: map[string]repositories.Admin{}}
+	for _, admin := range admins {
+		r.admins[admin.Email] = admin
+	}
+	return r
+}
+
+func (r *memoryAdminRepository) CreateAdmin(_ context.Context, admin *repositories.Admin) error {
+	if _, ok := r.admins[admin.Email]; ok {
+		return repositories.ErrAdminExists
+	}
+	r.admins[admin.Email] = *admin
+	return nil
+}
+
+func (r *memoryAdminRepository) DeleteAdmin(_ context.Context, email string) error {
+	admin, ok := r.admins[email]
+	if !ok {
+		return repositories.ErrAdminNotFound
+	}
+	if admin.Role == repositories.AdminRoleOwner {
+		owners := 0
+		for _, other := range r.admins {
+			if other.Role == repositories.AdminRoleOwner {
+				owners++
+			}
+		}
+		if owners == 1 {
+			return repositories.ErrLastOwner
+		}
+	}
+	delete(r.admins, email)
+	return nil
+}
+
+func TestInviteAdmin(t *testing.T) {
+	ctx := context.Background()
+	notifications, sent := newTestNotificationService(t, &config.Config{})
+	repo := newMemoryAdminRepository()
+	s := NewAdminService(repo, newMemorySessionRepository(), notifications, &config.Config{AdminEmail: "owner@example.com", FrontendURL: "https://chanterelle.example/"})
+
+	admin, err := s.InviteAdmin(ctx, " Editor@Example.com ", repositories.AdminRoleEditor, "owner@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "editor@example.com", admin.Email)
+	assert.Equal(t, repositories.AdminRoleEditor, repo.admins["editor@example.com"].Role)
+
+	emails := sent.all()
+	require.Len(t, emails, 1)
+	assert.Equal(t, "editor@example.com", emails[0].TemplateParams.Email)
+	assert.Contains(t, emails[0].TemplateParams.Message, "owner@example.com has given you editor access")
+	assert.Contains(t, emails[0].TemplateParams.Message, "https://chanterelle.example")
+
+	_, err = s.InviteAdmin(ctx, "editor@example.com", repositories.AdminRoleViewer, "owner@example.com")
+	assert.ErrorIs(t, err, repositories.ErrAdminExists)
+
+	_, err = s.InviteAdmin(ctx, "someone@example.com", repositories.AdminRole("superuser"), "owner@example.com")
+	assert.ErrorIs(t, err, ErrInvalidAdminRole)
+	assert.Len(t, sent.all(), 1)
+}
+
+func TestInviteAdminKeepsAdminWhenEmailFails(t *testing.T) {
+	repo := newMemoryAdminRepository()
+	// Without EmailJS settings the invite can't be sent.
+	s := NewAdminService(repo, newMemorySessionRepository(), NewNotificationService(&config.Config{}), &config.Config{})
+
+	admin, err := s.InviteAdmin(context.Background(), "viewer@example.com", repositories.AdminRoleViewer, "owner@example.com")
+	assert.Error(t, err)
+	assert.Equal(t, "viewer@example.com", admin.Email)
+	assert.Contains(t, repo.admins, "viewer@example.com")
+}
+
+func TestRemoveAdmin(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryAdminRepository(
+		repositories.Admin{Email: "owner@example.com", Role: repositories.AdminRoleOwner},
+		repositories.Admin{Email: "second@example.com", Role: repositories.AdminRoleOwner},
+		repositories.Admin{Email: "editor@example.com", Role: repositories.AdminRoleEditor},
+	)
+	sessions := newMemorySessionRepository()
+	s := NewAdminService(repo, sessions, nil, &config.Config{AdminEmail: "Owner@Example.com"})
+	require.NoError(t, sessions.CreateSession(ctx, &repositories.Session{Email: "editor@example.com", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	require.NoError(t, s.RemoveAdmin(ctx, "Editor@Example.com"))
+	assert.NotContains(t, repo.admins, "editor@example.com")
+	active, err := sessions.GetActiveSessions(ctx, "editor@example.com", time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, active, "a removed admin is signed out")
+
+	err = s.RemoveAdmin(ctx, "editor@example.com")
+	assert.ErrorIs(t, err, repositories.ErrAdminNotFound)
+
+	// ADMIN_EMAIL would be restored as an owner at the next start.
+	err = s.RemoveAdmin(ctx, "owner@example.com")
+	assert.ErrorIs(t, err, ErrProtectedAdmin)
+
+	require.NoError(t, s.RemoveAdmin(ctx, "second@example.com"))
+
+	// With ADMIN_EMAIL changed, the remaining owner is still kept.
+	s = NewAdminService(repo, sessions, nil, &config.Config{AdminEmail: "new@example.com"})
+	err = s.RemoveAdmin(ctx, "owner@example.com")
+	assert.ErrorIs(t, err, ErrProtectedAdmin)
+	assert.Contains(t, repo.admins, "owner@example.com")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+// AutoReplyService acknowledges form submissions so senders know their
+// message arrived. The template comes from the category's routing rule,
+// falling back to EMAILJS_AUTO_REPLY_TEMPLATE_ID.
+type AutoReplyService struct {
+	repository          repositories.AutoReplyRepository
+	routingService      *RoutingService
+	notificationService *NotificationService
+	enabled             bool
+	window              time.Duration
+	defaultTemplateID   string
+}
+
+func NewAutoReplyService(repository repositories.AutoReplyRepository, routingService *RoutingService, notificationService *NotificationService, cfg *config.Config) *AutoReplyService {
+	return &AutoReplyService{
+		repository:          repository,
+		routingService:      routingService,
+		notificationService: notificationService,
+		enabled:             cfg.AutoReplyEnabled,
+		window:              cfg.AutoReplyWindow,
+		defaultTemplateID:   cfg.EmailJSAutoReplyTemplateID,
+	}
+}
+
+// Acknowledge sends the auto-reply for a stored contact and reports whether
+// one was sent. Nothing is sent while auto-replies are switched off, when
+// the category has no template, or when the sender already had an
+// auto-reply within the suppression window.
+func (s *AutoReplyService) Acknowledge(ctx context.Context, contact *repositories.Contact) (bool, error) {
+	if !s.enabled {
+		return false, nil
+	}
+
+	rule, err := s.routingService.GetRoutingRule(ctx, contact.Category)
+	if err != nil {
+		return false, err
+	}
+	templateID := rule.AutoReplyTemplateID
+	if templateID == "" {
+		templateID = s.defaultTemplateID
+	}
+	if templateID == "" {
+		return false, nil
+	}
+
+	if s.window > 0 {
+		// The claim is kept even if sending fails, so a flaky email provider
+		// can't turn into a burst of retried replies.
+		claimed, err := s.repository.ClaimAutoReply(ctx, normalizeEmail(contact.Email), time.Now(), s.window)
+		if err != nil || !claimed {
+			return false, err
+		}
+	}
+
+	// The submitted message is left out so the form can't be used to send
+	// arbitrary text to any address from our sender.
+	if err := s.notificationService.SendAutoReply(&models.Contact{
+		Name:  contact.Name,
+		Email: contact.Email,
+	}, templateID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAutoReplyRepository is an in-memory AutoReplyRepository for tests.
+type memoryAutoReplyRepository struct {
+	repositories.AutoReplyRepository
+	lastSent map[string]time.Time
+}
+
+func (r *memoryAutoReplyRepository) ClaimAutoReply(_ context.Context, email string, now time.Time, window time.Duration) (bool, error) {
+	if last, ok := r.lastSent[email]; ok && now.Sub(last) < window {
+		return false, nil
+	}
+	r.lastSent[email] = now
+	return true, nil
+}
+
+func TestAcknowledgeDoesNotEchoSubmission(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{AutoReplyEnabled: true, AutoReplyWindow: 24 * time.Hour, EmailJSAutoReplyTemplateID: "template_thanks"}
+	notifications, sent := newTestNotificationService(t, cfg)
+	routing := NewRoutingService(newMemoryRoutingRuleRepository(), notifications, nil, cfg)
+	s := NewAutoReplyService(&memoryAutoReplyRepository{lastSent: map[string]time.Time{}}, routing, notifications, cfg)
+
+	contact := &repositories.Contact{
+		ID:       primitive.NewObjectID(),
+		Name:     "Win a prize at http://spam.example",
+		Email:    "victim@example.com",
+		Message:  "Click http://spam.example to claim your prize",
+		Category: repositories.ContactCategoryGeneral,
+	}
+	replied, err := s.Acknowledge(ctx, contact)
+	require.NoError(t, err)
+	assert.True(t, replied)
+
+	emails := sent.all()
+	require.Len(t, emails, 1)
+	assert.Equal(t, "template_thanks", emails[0].TemplateID)
+	params := emails[0].TemplateParams
+	assert.Equal(t, "victim@example.com", params.Email)
+	assert.Empty(t, params.Message)
+	assert.Equal(t, "there", params.ToName)
+	assert.NotContains(t, params.Firstname+params.Lastname+params.Destination, "spam.example")
+
+	// One auto-reply per address per window.
+	replied, err = s.Acknowledge(ctx, contact)
+	require.NoError(t, err)
+	assert.False(t, replied)
+	assert.Len(t, sent.all(), 1)
+}
+
+func TestAutoReplyName(t *testing.T) {
+	tests := map[string]string{
+		"Alex Smith":                   "Alex Smith",
+		"  Alex \n Smith ":             "Alex Smith",
+		"":                             "there",
+		"www.spam.example":             "there",
+		"Email me at spam@example.com": "there",
+		"<b>Alex</b>":                  "there",
+		"An extremely long name that carries a sentence": "there",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, autoReplyName(name), name)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	DefaultBookingPageSize = 50
+	MaxBookingPageSize     = 200
+)
+
+var (
+	ErrInvalidBooking = errors.New("invalid booking inquiry")
+	ErrPastEventDate  = errors.New("event date is in the past")
+)
+
+type BookingService struct {
+	repository          repositories.BookingRepository
+	notificationService *NotificationService
+	spamService         *SpamService
+	validate            *validator.Validate
+}
+
+func NewBookingService(repository repositories.BookingRepository, notificationService *NotificationService, spamService *SpamService) *BookingService {
+	validate := validator.New()
+	// Report fields by their JSON names, which is what the form sends.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return &BookingService{
+		repository:          repository,
+		notificationService: notificationService,
+		spamService:         spamService,
+		validate:            validate,
+	}
+}
+
+// SubmitBooking validates and stores a booking inquiry from ip, then lets
+// the admin know about it. Inquiries the spam checks flag are quarantined
+// without a notification. A failed notification is logged rather than
+// returned since the inquiry itself has been saved.
+func (s *BookingService) SubmitBooking(ctx context.Context, inquiry models.BookingInquiry, ip, userAgent string) (repositories.Booking, error) {
+	if errs := validationMessages(s.validate.Struct(inquiry)); len(errs) > 0 {
+		return repositories.Booking{}, fmt.Errorf("%w: %s", ErrInvalidBooking, strings.Join(errs, "; "))
+	}
+
+	eventDate, err := time.Parse("2006-01-02", inquiry.EventDate)
+	if err != nil {
+		return repositories.Booking{}, fmt.Errorf("%w: %v", ErrInvalidBooking, err)
+	}
+	// Allow a day of slack for venues in time zones ahead of UTC.
+	if eventDate.Before(time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)) {
+		return repositories.Booking{}, ErrPastEventDate
+	}
+
+	booking := repositories.Booking{
+		Name:      strings.TrimSpace(inquiry.Name),
+		Email:     strings.TrimSpace(inquiry.Email),
+		Phone:     strings.TrimSpace(inquiry.Phone),
+		EventType: inquiry.EventType,
+		EventDate: eventDate,
+		Venue:     strings.TrimSpace(inquiry.Venue),
+		City:      strings.TrimSpace(inquiry.City),
+		Capacity:  inquiry.Capacity,
+		Budget:    strings.TrimSpace(inquiry.Budget),
+		Message:   strings.TrimSpace(inquiry.Message),
+	}
+
+	verdict := s.spamService.Evaluate(ctx, Submission{
+		Name:       booking.Name,
+		Email:      booking.Email,
+		Message:    strings.Join([]string{booking.Venue, booking.Budget, booking.Message}, "\n"),
+		Honeypot:   inquiry.Website,
+		FormToken:  inquiry.FormToken,
+		IP:         ip,
+		UserAgent:  userAgent,
+		ReceivedAt: time.Now(),
+	})
+	if verdict.Spam {
+		booking.Spam = true
+		booking.SpamScore = verdict.Score
+		booking.SpamReasons = verdict.Reasons
+	}
+
+	if err := s.repository.CreateBooking(ctx, &booking); err != nil {
+		return repositories.Booking{}, err
+	}
+	if booking.Spam {
+		log.Printf("Quarantined booking inquiry %s as spam (score %d): %s", booking.ID.Hex(), verdict.Score, strings.Join(verdict.Reasons, "; "))
+		return booking, nil
+	}
+
+	if err := s.notificationService.SendBookingNotification(&booking); err != nil {
+		log.Printf("Failed to send booking notification for %s: %v", booking.ID.Hex(), err)
+	}
+
+	return booking, nil
+}
+
+func (s *BookingService) GetBookings(ctx context.Context, filter repositories.BookingFilter) ([]repositories.Booking, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultBookingPageSize
+	}
+	if filter.Limit > MaxBookingPageSize {
+		filter.Limit = MaxBookingPageSize
+	}
+	return s.repository.GetBookings(ctx, filter)
+}
+
+func (s *BookingService) GetBookingByID(ctx context.Context, id string) (repositories.Booking, error) {
+	return s.repository.GetBookingByID(ctx, id)
+}
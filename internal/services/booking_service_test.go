@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBookingRepository is an in-memory BookingRepository for tests.
+type memoryBookingRepository struct {
+	repositories.BookingRepository
+	bookings []repositories.Booking
+}
+
+func (r *memoryBookingRepository) CreateBooking(_ context.Context, booking *repositories.Booking) error {
+	booking.ID = primitive.NewObjectID()
+	booking.CreatedAt = time.Now()
+	r.bookings = append(r.bookings, *booking)
+	return nil
+}
+
+func TestSubmitBookingRunsSpamChecks(t *testing.T) {
+	ctx := context.Background()
+	spam := newTestSpamService()
+	token, err := spam.IssueFormToken(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	inquiry := models.BookingInquiry{
+		Name:      "Alex",
+		Email:     "alex@example.com",
+		EventType: "wedding",
+		EventDate: time.Now().AddDate(0, 2, 0).Format("2006-01-02"),
+		Venue:     "The Barn",
+		City:      "Bristol",
+		Message:   "We'd love you to play our first dance",
+		FormToken: token,
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*models.BookingInquiry)
+		spam     bool
+		reason   string
+		notified bool
+	}{
+		{name: "genuine", modify: func(*models.BookingInquiry) {}, notified: true},
+		{name: "honeypot", modify: func(i *models.BookingInquiry) { i.Website = "http://spam.example" }, spam: true, reason: "honeypot field was filled in"},
+		{name: "replayed token", modify: func(*models.BookingInquiry) {}, spam: true, reason: "form token was already used"},
+		{name: "forged token", modify: func(i *models.BookingInquiry) { i.FormToken += "x" }, spam: true, reason: "form token is invalid"},
+		{name: "blocked keyword in venue", modify: func(i *models.BookingInquiry) {
+			i.FormToken = ""
+			i.Venue = "Buy crypto now"
+		}, spam: true, reason: `contains blocked keyword "crypto"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifications, sent := newTestNotificationService(t, &config.Config{})
+			bookings := &memoryBookingRepository{}
+			s := NewBookingService(bookings, notifications, spam)
+
+			submitted := inquiry
+			tt.modify(&submitted)
+			booking, err := s.SubmitBooking(ctx, submitted, "192.0.2.1", "test")
+			require.NoError(t, err)
+
+			require.Len(t, bookings.bookings, 1, "spam is quarantined, not dropped")
+			assert.Equal(t, tt.spam, booking.Spam)
+			if tt.spam {
+				assert.Contains(t, booking.SpamReasons, tt.reason)
+			}
+			if tt.notified {
+				assert.Len(t, sent.all(), 1)
+			} else {
+				assert.Empty(t, sent.all())
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+// Consent sources name where someone agreed to join the mailing list.
+const (
+	ConsentSourceContactForm = "contact_form"
+	ConsentSourceImport      = "import"
+)
+
+// ErrConsentTextChanged means a signup agreed to consent wording that is no
+// longer the current one.
+var ErrConsentTextChanged = errors.New("the mailing list wording has changed, please review it and try again")
+
+// ConsentEvidence is what the request that gave consent tells us about who
+// gave it.
+type ConsentEvidence struct {
+	IP        string
+	UserAgent string
+}
+
+// SignupConsent is someone ticking the mailing list box on a form: the
+// wording they were shown and the request they did it in.
+type SignupConsent struct {
+	Text     string
+	Evidence ConsentEvidence
+}
+
+// ConsentService keeps proof of consent for everyone who is subscribed.
+type ConsentService struct {
+	repository repositories.ConsentRepository
+	people     repositories.PersonRepository
+	text       string
+}
+
+func NewConsentService(repository repositories.ConsentRepository, people repositories.PersonRepository, cfg *config.Config) *ConsentService {
+	return &ConsentService{
+		repository: repository,
+		people:     people,
+		text:       cfg.ConsentText,
+	}
+}
+
+// ConsentText is the wording the contact form shows next to the signup.
+func (s *ConsentService) ConsentText() string {
+	return s.text
+}
+
+// ConsentVersion identifies the current consent wording. The contact form
+// sends it back with a signup to show which wording was agreed to.
+func (s *ConsentService) ConsentVersion() string {
+	sum := sha256.Sum256([]byte(s.text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignupConsent returns the consent given by agreeing to the wording
+// identified by version, or ErrConsentTextChanged if that is not the
+// current wording.
+func (s *ConsentService) SignupConsent(version string, evidence ConsentEvidence) (SignupConsent, error) {
+	if version != s.ConsentVersion() {
+		return SignupConsent{}, ErrConsentTextChanged
+	}
+	return SignupConsent{Text: s.text, Evidence: evidence}, nil
+}
+
+// RecordConsent stores an immutable consent record for a contact.
+func (s *ConsentService) RecordConsent(ctx context.Context, contact *repositories.Contact, event repositories.ConsentEvent, source, text string, evidence ConsentEvidence) error {
+	return s.repository.RecordConsent(ctx, &repositories.Consent{
+		ContactID: contact.ID,
+		Email:     normalizeEmail(contact.Email),
+		Event:     event,
+		Text:      text,
+		Source:    source,
+		IP:        evidence.IP,
+		UserAgent: evidence.UserAgent,
+	})
+}
+
+// HasConsent reports whether a contact already has a consent record for
+// event.
+func (s *ConsentService) HasConsent(ctx context.Context, contact *repositories.Contact, event repositories.ConsentEvent) (bool, error) {
+	consents, err := s.repository.GetConsentsByEmails(ctx, []string{normalizeEmail(contact.Email)})
+	if err != nil {
+		return false, err
+	}
+	for _, consent := range consents {
+		if consent.ContactID == contact.ID && consent.Event == event {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetPersonConsents lists the consents given from any of a person's
+// addresses.
+func (s *ConsentService) GetPersonConsents(ctx context.Context, personID string) ([]repositories.Consent, error) {
+	person, err := s.people.GetPersonByID(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.GetConsentsByEmails(ctx, person.Emails)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"chanterelle/internal/repositories"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// ExportFormat is a file format contacts can be exported as.
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	ExportFormatVCard  ExportFormat = "vcard"
+)
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case ExportFormatVCard:
+		return "text/vcard; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportFormatNDJSON:
+		return "ndjson"
+	case ExportFormatVCard:
+		return "vcf"
+	}
+	return string(f)
+}
+
+// contactRecord is the flat shape of a contact in CSV and NDJSON exports.
+type contactRecord struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Message   string   `json:"message"`
+	Status    string   `json:"status"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"created_at"`
+}
+
+var contactRecordHeader = []string{"id", "name", "email", "message", "status", "tags", "created_at"}
+
+func newContactRecord(contact repositories.Contact) contactRecord {
+	return contactRecord{
+		ID:        contact.ID.Hex(),
+		Name:      contact.Name,
+		Email:     contact.Email,
+		Message:   contact.Message,
+		Status:    string(contact.Status),
+		Tags:      append([]string{}, contact.Tags...),
+		CreatedAt: contact.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func (r contactRecord) csvRow() []string {
+	return []string{
+		r.ID,
+		escapeCSVFormula(r.Name),
+		escapeCSVFormula(r.Email),
+		escapeCSVFormula(r.Message),
+		r.Status,
+		escapeCSVFormula(strings.Join(r.Tags, "; ")),
+		r.CreatedAt,
+	}
+}
+
+// escapeCSVFormula stops spreadsheets from running submitted text as a
+// formula by prefixing cells that start with a formula character. Phone
+// numbers such as "+44 20 7946 0000" are left alone.
+func escapeCSVFormula(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) || isPhoneNumber(value) {
+		return value
+	}
+	return "'" + value
+}
+
+// isPhoneNumber reports whether value is digits and spaces with an optional
+// leading plus sign.
+func isPhoneNumber(value string) bool {
+	digits := strings.TrimPrefix(value, "+")
+	if strings.Trim(digits, " ") == "" {
+		return false
+	}
+	for _, r := range digits {
+		if r != ' ' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportContacts streams every contact matching the filter to w in the given
+// format. Contacts are written as they are read from the repository.
+func (s *ContactService) ExportContacts(ctx context.Context, filter repositories.ContactFilter, format ExportFormat, w io.Writer) error {
+	switch format {
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(contactRecordHeader); err != nil {
+			return err
+		}
+		err := s.repository.StreamContacts(ctx, filter, func(contact repositories.Contact) error {
+			return cw.Write(newContactRecord(contact).csvRow())
+		})
+		cw.Flush()
+		if err != nil {
+			return err
+		}
+		return cw.Error()
+
+	case ExportFormatNDJSON:
+		enc := json.NewEncoder(w)
+		return s.repository.StreamContacts(ctx, filter, func(contact repositories.Contact) error {
+			return enc.Encode(newContactRecord(contact))
+		})
+
+	case ExportFormatVCard:
+		return s.repository.StreamContacts(ctx, filter, func(contact repositories.Contact) error {
+			_, err := io.WriteString(w, contactVCard(contact))
+			return err
+		})
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownExportFormat, format)
+}
+
+// contactVCard renders a contact as a vCard 4.0 (RFC 6350) entry.
+func contactVCard(contact repositories.Contact) string {
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		"UID:urn:chanterelle:contact:" + contact.ID.Hex(),
+		"FN:" + escapeVCardText(contact.Name),
+		"EMAIL:" + escapeVCardText(contact.Email),
+	}
+	if contact.Message != "" {
+		lines = append(lines, "NOTE:"+escapeVCardText(contact.Message))
+	}
+	if len(contact.Tags) > 0 {
+		categories := make([]string, len(contact.Tags))
+		for i, tag := range contact.Tags {
+			categories[i] = escapeVCardText(tag)
+		}
+		lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	lines = append(lines,
+		"REV:"+contact.CreatedAt.UTC().Format("20060102T150405Z"),
+		"END:VCARD",
+	)
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldVCardLine(line))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+var vcardEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	",", `\,`,
+	";", `\;`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeVCardText(value string) string {
+	return vcardEscaper.Replace(value)
+}
+
+// foldVCardLine splits lines longer than 75 octets, continuing each with a
+// single leading space, without breaking UTF-8 sequences.
+func foldVCardLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
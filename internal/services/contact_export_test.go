@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryContactRepository is an in-memory ContactRepository for tests.
+type memoryContactRepository struct {
+	repositories.ContactRepository
+	contacts []*repositories.Contact
+}
+
+func (r *memoryContactRepository) StreamContacts(_ context.Context, _ repositories.ContactFilter, fn func(repositories.Contact) error) error {
+	for _, contact := range r.contacts {
+		if contact.DeletedAt != nil {
+			continue
+		}
+		if err := fn(*contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportContactsCSVEscapesFormulas(t *testing.T) {
+	contacts := &memoryContactRepository{contacts: []*repositories.Contact{{
+		ID:        primitive.NewObjectID(),
+		Name:      "=HYPERLINK(\"http://evil.example\")",
+		Email:     "alex@example.com",
+		Message:   "Hello, \"world\"\nsecond line",
+		Status:    repositories.ContactStatusNew,
+		Tags:      []string{"@vip", "press"},
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}}}
+	s := NewContactService(contacts, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, s.ExportContacts(context.Background(), repositories.ContactFilter{}, ExportFormatCSV, &out))
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, contactRecordHeader, rows[0])
+	assert.Equal(t, `'=HYPERLINK("http://evil.example")`, rows[1][1])
+	assert.Equal(t, "alex@example.com", rows[1][2])
+	assert.Equal(t, "Hello, \"world\"\nsecond line", rows[1][3])
+	assert.Equal(t, "'@vip; press", rows[1][5])
+	assert.Equal(t, "2024-05-01T12:00:00Z", rows[1][6])
+}
+
+func TestEscapeCSVFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Alex", "Alex"},
+		{"a=b", "a=b"},
+		{"=1+1", "'=1+1"},
+		{"+44 20 7946 0000", "+44 20 7946 0000"},
+		{"+", "'+"},
+		{"+44 (20)", "'+44 (20)"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeCSVFormula(tt.value), tt.value)
+	}
+}
+
+func TestContactVCardEscapesAndFolds(t *testing.T) {
+	contact := repositories.Contact{
+		ID:        primitive.NewObjectID(),
+		Name:      `Smith; Alex, Jr\`,
+		Email:     "alex@example.com",
+		Message:   strings.Repeat("é", 50) + "\nbye",
+		Tags:      []string{"a,b", "press"},
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	card := contactVCard(contact)
+	assert.True(t, strings.HasPrefix(card, "BEGIN:VCARD\r\nVERSION:4.0\r\n"))
+	assert.True(t, strings.HasSuffix(card, "REV:20240501T120000Z\r\nEND:VCARD\r\n"))
+	assert.Contains(t, card, "\r\nFN:Smith\\; Alex\\, Jr\\\\\r\n")
+	assert.Contains(t, card, "\r\nCATEGORIES:a\\,b,press\r\n")
+
+	// Physical lines are at most 75 octets and unfold back to the escaped
+	// note without splitting any UTF-8 sequence.
+	for _, line := range strings.Split(strings.TrimSuffix(card, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	unfolded := strings.ReplaceAll(card, "\r\n ", "")
+	assert.Contains(t, unfolded, "\r\nNOTE:"+strings.Repeat("é", 50)+"\\nbye\r\n")
+}
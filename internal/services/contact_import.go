@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+var (
+	ErrInvalidImportFile  = errors.New("invalid csv file")
+	ErrMissingEmailColumn = errors.New("csv header must include an email column")
+	// ErrMissingConsentColumn means a subscribing import has no record of
+	// how its contacts agreed to join the mailing list.
+	ErrMissingConsentColumn = errors.New("csv header must include a consent column to subscribe contacts")
+
+	errNoImportConsent = errors.New("not subscribed: the consent column is empty")
+)
+
+// ImportAction is what happened, or would happen in a dry run, to one row of
+// an import.
+type ImportAction string
+
+const (
+	ImportActionCreated     ImportAction = "created"
+	ImportActionWouldCreate ImportAction = "would_create"
+	ImportActionDuplicate   ImportAction = "duplicate"
+	ImportActionInvalid     ImportAction = "invalid"
+	ImportActionFailed      ImportAction = "failed"
+)
+
+type ImportOptions struct {
+	// DryRun validates and deduplicates without writing anything or
+	// contacting Mailchimp.
+	DryRun bool
+	// Subscribe queues each created contact to be added to the Mailchimp
+	// list by the background sync. The file must then have a consent column
+	// saying how each person agreed to join, which is stored as their
+	// consent record; rows with an empty consent are not subscribed.
+	Subscribe bool
+}
+
+// ImportRowResult reports the outcome for one CSV row. Row numbers count
+// the header as row 1, matching what a spreadsheet shows.
+type ImportRowResult struct {
+	Row            int          `json:"row"`
+	Name           string       `json:"name"`
+	Email          string       `json:"email"`
+	Action         ImportAction `json:"action"`
+	Errors         []string     `json:"errors,omitempty"`
+	Subscribed     bool         `json:"subscribed,omitempty"`
+	SubscribeError string       `json:"subscribe_error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Created    int               `json:"created"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Failed     int               `json:"failed"`
+	Subscribed int               `json:"subscribed"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+type ImportService struct {
+	contactService *ContactService
+	mailchimpSync  *MailchimpSyncService
+	consentService *ConsentService
+	validate       *validator.Validate
+}
+
+func NewImportService(contactService *ContactService, mailchimpSync *MailchimpSyncService, consentService *ConsentService) *ImportService {
+	return &ImportService{
+		contactService: contactService,
+		mailchimpSync:  mailchimpSync,
+		consentService: consentService,
+		validate:       validator.New(),
+	}
+}
+
+// ImportCSV reads contacts from CSV with a header row containing name,
+// email and optionally message and consent columns. Rows are validated against the
+// models.Contact rules and skipped when their email already exists, either
+// in the repository or earlier in the same file.
+func (s *ImportService) ImportCSV(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidImportFile, err)
+	}
+	columns := importColumns(header)
+	if _, ok := columns["email"]; !ok {
+		return nil, ErrMissingEmailColumn
+	}
+	if _, ok := columns["consent"]; opts.Subscribe && !ok {
+		return nil, ErrMissingConsentColumn
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Rows: []ImportRowResult{}}
+	seen := map[string]bool{}
+	row := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			report.Invalid++
+			report.Rows = append(report.Rows, ImportRowResult{
+				Row:    row,
+				Action: ImportActionInvalid,
+				Errors: []string{err.Error()},
+			})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+
+		contact := models.Contact{
+			Name:    columnValue(record, columns, "name"),
+			Email:   columnValue(record, columns, "email"),
+			Message: columnValue(record, columns, "message"),
+		}
+		result := ImportRowResult{Row: row, Name: contact.Name, Email: contact.Email}
+
+		if errs := s.validateContact(contact); len(errs) > 0 {
+			result.Action = ImportActionInvalid
+			result.Errors = errs
+			report.Invalid++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		key := normalizeEmail(contact.Email)
+		exists := seen[key]
+		if !exists {
+			exists, err = s.contactService.ContactExistsByEmail(ctx, contact.Email)
+			if err != nil {
+				return nil, err
+			}
+		}
+		seen[key] = true
+		if exists {
+			result.Action = ImportActionDuplicate
+			report.Duplicates++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		if opts.DryRun {
+			result.Action = ImportActionWouldCreate
+			report.Created++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		stored := &repositories.Contact{
+			Name:    contact.Name,
+			Email:   contact.Email,
+			Message: contact.Message,
+			Source:  repositories.ContactSourceImport,
+		}
+		if err := s.contactService.CreateContact(ctx, stored); err != nil {
+			result.Action = ImportActionFailed
+			result.Errors = []string{err.Error()}
+			report.Failed++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+		result.Action = ImportActionCreated
+		report.Created++
+
+		if opts.Subscribe {
+			consent := columnValue(record, columns, "consent")
+			if consent == "" {
+				result.SubscribeError = errNoImportConsent.Error()
+			} else if err := s.consentService.RecordConsent(ctx, stored, repositories.ConsentSignup,
+				ConsentSourceImport, consent, ConsentEvidence{}); err != nil {
+				result.SubscribeError = err.Error()
+			} else if err := s.mailchimpSync.Queue(ctx, stored.ID); err != nil {
+				result.SubscribeError = err.Error()
+			} else {
+				result.Subscribed = true
+				report.Subscribed++
+			}
+		}
+
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+func (s *ImportService) validateContact(contact models.Contact) []string {
+	return validationMessages(s.validate.Struct(contact))
+}
+
+// validationMessages turns validator errors into readable messages naming
+// the lower-cased field.
+func validationMessages(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		field := strings.ToLower(fieldErr.Field())
+		switch fieldErr.Tag() {
+		case "required":
+			messages = append(messages, field+" is required")
+		case "email":
+			messages = append(messages, field+" is not a valid email address")
+		case "min":
+			messages = append(messages, fmt.Sprintf("%s must be at least %s characters", field, fieldErr.Param()))
+		case "max":
+			messages = append(messages, fmt.Sprintf("%s must be at most %s characters", field, fieldErr.Param()))
+		case "oneof":
+			messages = append(messages, fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fieldErr.Param(), " ", ", ")))
+		case "datetime":
+			messages = append(messages, fmt.Sprintf("%s must be a date formatted as %s", field, fieldErr.Param()))
+		default:
+			messages = append(messages, fmt.Sprintf("%s failed %s validation", field, fieldErr.Tag()))
+		}
+	}
+	return messages
+}
+
+// importColumns maps lower-cased header names to their column index.
+func importColumns(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	return columns
+}
+
+func columnValue(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
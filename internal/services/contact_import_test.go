@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryContactRepository) CreateContact(_ context.Context, contact *repositories.Contact) error {
+	contact.ID = primitive.NewObjectID()
+	contact.CreatedAt = time.Now()
+	contact.Status = repositories.ContactStatusNew
+	stored := *contact
+	r.contacts = append(r.contacts, &stored)
+	return nil
+}
+
+func (r *memoryContactRepository) ContactExistsByEmail(_ context.Context, email string) (bool, error) {
+	for _, contact := range r.contacts {
+		if strings.EqualFold(contact.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// memoryPersonRepository is an in-memory PersonRepository for tests.
+type memoryPersonRepository struct {
+	repositories.PersonRepository
+	people map[primitive.ObjectID]*repositories.Person
+	// beforeMerge runs in MergePeople after both people are read.
+	beforeMerge func()
+}
+
+func newMemoryPersonRepository() *memoryPersonRepository {
+	return &memoryPersonRepository{people: map[primitive.ObjectID]*repositories.Person{}}
+}
+
+func (r *memoryPersonRepository) RecordSubmission(_ context.Context, email, name string, seenAt time.Time) (repositories.Person, error) {
+	for _, person := range r.people {
+		for _, known := range person.Emails {
+			if known == email {
+				person.MessageCount++
+				person.LastSeenAt = seenAt
+				return *person, nil
+			}
+		}
+	}
+	person := &repositories.Person{
+		ID:           primitive.NewObjectID(),
+		Email:        email,
+		Emails:       []string{email},
+		Name:         name,
+		MessageCount: 1,
+		FirstSeenAt:  seenAt,
+		LastSeenAt:   seenAt,
+	}
+	r.people[person.ID] = person
+	return *person, nil
+}
+
+func newTestImportService(existing ...string) (*ImportService, *memoryContactRepository) {
+	contacts := &memoryContactRepository{}
+	for _, email := range existing {
+		contacts.contacts = append(contacts.contacts, &repositories.Contact{ID: primitive.NewObjectID(), Name: "Existing", Email: email})
+	}
+	return NewImportService(NewContactService(contacts, newMemoryPersonRepository()), nil, nil), contacts
+}
+
+func TestImportCSV(t *testing.T) {
+	const file = "\ufeffEmail, Name ,Message\n" +
+		"alex@example.com,Alex,Hello\n" +
+		"ALEX@example.com,Alex again,Duplicate within the file\n" +
+		"sam@example.com,Sam,Already a contact\n" +
+		",,\n" +
+		"not-an-email,A,\n" +
+		"\"broken,quote\n"
+
+	tests := []struct {
+		name    string
+		dryRun  bool
+		created ImportAction
+	}{
+		{name: "import", created: ImportActionCreated},
+		{name: "dry run", dryRun: true, created: ImportActionWouldCreate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, contacts := newTestImportService("Sam@Example.com")
+
+			report, err := s.ImportCSV(context.Background(), strings.NewReader(file), ImportOptions{DryRun: tt.dryRun})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.dryRun, report.DryRun)
+			assert.Equal(t, 1, report.Created)
+			assert.Equal(t, 2, report.Duplicates)
+			assert.Equal(t, 2, report.Invalid)
+			assert.Zero(t, report.Failed)
+
+			require.Len(t, report.Rows, 5)
+			assert.Equal(t, ImportRowResult{Row: 2, Name: "Alex", Email: "alex@example.com", Action: tt.created}, report.Rows[0])
+			assert.Equal(t, ImportActionDuplicate, report.Rows[1].Action)
+			assert.Equal(t, ImportActionDuplicate, report.Rows[2].Action)
+			// The blank row 5 is skipped without a result.
+			assert.Equal(t, 6, report.Rows[3].Row)
+			assert.Equal(t, []string{"name must be at least 2 characters", "email is not a valid email address"}, report.Rows[3].Errors)
+			assert.Equal(t, 7, report.Rows[4].Row)
+			assert.Equal(t, ImportActionInvalid, report.Rows[4].Action)
+
+			if tt.dryRun {
+				assert.Len(t, contacts.contacts, 1)
+				return
+			}
+			require.Len(t, contacts.contacts, 2)
+			stored := contacts.contacts[1]
+			assert.Equal(t, "Hello", stored.Message)
+			assert.Equal(t, repositories.ContactSourceImport, stored.Source)
+			assert.NotNil(t, stored.PersonID)
+		})
+	}
+}
+
+func TestImportCSVSubscribeRecordsConsent(t *testing.T) {
+	const file = "email,name,consent\n" +
+		"alex@example.com,Alex,Signed the mailing list sheet at the March show\n" +
+		"sam@example.com,Sam,\n"
+
+	contacts := &memoryContactRepository{}
+	consents := &memoryConsentRepository{}
+	people := newMemoryPersonRepository()
+	mailchimpSync := NewMailchimpSyncService(contacts, NewNotificationService(&config.Config{}), &config.Config{})
+	s := NewImportService(NewContactService(contacts, people), mailchimpSync, NewConsentService(consents, people, &config.Config{}))
+
+	report, err := s.ImportCSV(context.Background(), strings.NewReader(file), ImportOptions{Subscribe: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Subscribed)
+	require.Len(t, report.Rows, 2)
+	assert.True(t, report.Rows[0].Subscribed)
+	assert.False(t, report.Rows[1].Subscribed)
+	assert.Equal(t, errNoImportConsent.Error(), report.Rows[1].SubscribeError)
+
+	require.Len(t, consents.consents, 1)
+	consent := consents.consents[0]
+	assert.Equal(t, "alex@example.com", consent.Email)
+	assert.Equal(t, "Signed the mailing list sheet at the March show", consent.Text)
+	assert.Equal(t, ConsentSourceImport, consent.Source)
+
+	require.Len(t, contacts.contacts, 2)
+	require.NotNil(t, contacts.contacts[0].MailchimpSync)
+	assert.Equal(t, repositories.MailchimpSyncPending, contacts.contacts[0].MailchimpSync.Status)
+	assert.Nil(t, contacts.contacts[1].MailchimpSync)
+}
+
+func TestImportCSVRejectsBadHeaders(t *testing.T) {
+	s, _ := newTestImportService()
+
+	_, err := s.ImportCSV(context.Background(), strings.NewReader("name,message\nAlex,Hi\n"), ImportOptions{})
+	assert.ErrorIs(t, err, ErrMissingEmailColumn)
+
+	_, err = s.ImportCSV(context.Background(), strings.NewReader(""), ImportOptions{})
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+
+	_, err = s.ImportCSV(context.Background(), strings.NewReader("name,email\nAlex,alex@example.com\n"), ImportOptions{Subscribe: true})
+	assert.ErrorIs(t, err, ErrMissingConsentColumn)
+}
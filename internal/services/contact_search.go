@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"chanterelle/internal/repositories"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// snippetLength is the approximate number of bytes of a long field shown
+	// around the first match.
+	snippetLength = 160
+)
+
+// ContactSearchHit is a search result with its relevance score and the
+// matching fields rendered as HTML-escaped snippets with <mark> around each
+// matched term.
+type ContactSearchHit struct {
+	Contact    repositories.Contact `json:"contact"`
+	Score      float64              `json:"score"`
+	Highlights map[string]string    `json:"highlights"`
+}
+
+// SearchContacts runs a ranked full-text search over name, email and
+// message. Repositories that implement repositories.ContactSearcher search
+// natively; anything else is scanned page by page and scored with the same
+// field weights.
+func (s *ContactService) SearchContacts(ctx context.Context, query string, limit int) ([]ContactSearchHit, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []ContactSearchHit{}, nil
+	}
+	matcher := termMatcher(terms)
+
+	var results []repositories.ContactSearchResult
+	var err error
+	if searcher, ok := s.repository.(repositories.ContactSearcher); ok {
+		results, err = searcher.SearchContacts(ctx, query, limit)
+	} else {
+		results, err = s.scanContacts(ctx, matcher, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]ContactSearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, ContactSearchHit{
+			Contact:    result.Contact,
+			Score:      result.Score,
+			Highlights: highlightContact(result.Contact, matcher),
+		})
+	}
+	return hits, nil
+}
+
+// scanContacts is the search fallback for repositories without a native
+// full-text index. Only the best-scoring results are kept in memory.
+func (s *ContactService) scanContacts(ctx context.Context, matcher *regexp.Regexp, limit int) ([]repositories.ContactSearchResult, error) {
+	results := []repositories.ContactSearchResult{}
+	filter := repositories.ContactFilter{Limit: MaxContactPageSize}
+	for {
+		page, err := s.repository.GetContacts(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, contact := range page.Contacts {
+			if score := scoreContact(contact, matcher); score > 0 {
+				results = append(results, repositories.ContactSearchResult{Contact: contact, Score: score})
+			}
+		}
+		sortSearchResults(results)
+		if len(results) > limit {
+			results = results[:limit]
+		}
+		if page.NextCursor == "" {
+			return results, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func sortSearchResults(results []repositories.ContactSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Contact.CreatedAt.After(results[j].Contact.CreatedAt)
+	})
+}
+
+// searchTerms splits a query into lower-cased words.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func termMatcher(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+func searchFields(contact repositories.Contact) map[string]string {
+	return map[string]string{
+		"name":    contact.Name,
+		"email":   contact.Email,
+		"message": contact.Message,
+	}
+}
+
+// scoreContact weights the number of term occurrences in each field.
+func scoreContact(contact repositories.Contact, matcher *regexp.Regexp) float64 {
+	var score float64
+	for field, value := range searchFields(contact) {
+		matches := len(matcher.FindAllStringIndex(value, -1))
+		score += float64(matches * repositories.ContactSearchWeights[field])
+	}
+	return score
+}
+
+func highlightContact(contact repositories.Contact, matcher *regexp.Regexp) map[string]string {
+	highlights := map[string]string{}
+	for field, value := range searchFields(contact) {
+		if snippet, ok := highlightField(value, matcher); ok {
+			highlights[field] = snippet
+		}
+	}
+	return highlights
+}
+
+// highlightField returns the part of value around its first match with
+// every match wrapped in <mark>. The text itself is HTML-escaped.
+func highlightField(value string, matcher *regexp.Regexp) (string, bool) {
+	matches := matcher.FindAllStringIndex(value, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(value)
+	if len(value) > snippetLength {
+		start = matches[0][0] - snippetLength/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + snippetLength
+		if end > len(value) {
+			end = len(value)
+		}
+		for start > 0 && !utf8.RuneStart(value[start]) {
+			start--
+		}
+		for end < len(value) && !utf8.RuneStart(value[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < pos || m[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(value[pos:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(value[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		pos = m[1]
+	}
+	b.WriteString(html.EscapeString(value[pos:end]))
+	if end < len(value) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
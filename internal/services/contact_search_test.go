@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreContact(t *testing.T) {
+	matcher := termMatcher(searchTerms("Burlington booking"))
+
+	agent := repositories.Contact{
+		Name:    "Sam Booking Agent",
+		Email:   "sam@burlington-booking.com",
+		Message: "Booking inquiry for a show in Burlington",
+	}
+	fan := repositories.Contact{
+		Name:    "Alex",
+		Email:   "alex@example.com",
+		Message: "Loved the show in Burlington!",
+	}
+	other := repositories.Contact{
+		Name:    "Jo",
+		Email:   "jo@example.com",
+		Message: "Hello",
+	}
+
+	assert.Greater(t, scoreContact(agent, matcher), scoreContact(fan, matcher))
+	assert.Greater(t, scoreContact(fan, matcher), 0.0)
+	assert.Equal(t, 0.0, scoreContact(other, matcher))
+}
+
+func TestHighlightField(t *testing.T) {
+	matcher := termMatcher(searchTerms("burlington"))
+
+	t.Run("short field", func(t *testing.T) {
+		snippet, ok := highlightField("Show in Burlington <VT>", matcher)
+		assert.True(t, ok)
+		assert.Equal(t, "Show in <mark>Burlington</mark> &lt;VT&gt;", snippet)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, ok := highlightField("Montpelier", matcher)
+		assert.False(t, ok)
+	})
+
+	t.Run("long field is trimmed around the match", func(t *testing.T) {
+		long := ""
+		for i := 0; i < 40; i++ {
+			long += "lorem ipsum "
+		}
+		snippet, ok := highlightField(long+"Burlington "+long, matcher)
+		assert.True(t, ok)
+		assert.Contains(t, snippet, "<mark>Burlington</mark>")
+		assert.True(t, len(snippet) < len(long))
+		assert.Equal(t, "…", snippet[:len("…")])
+	})
+}
@@ -2,36 +2,118 @@ package services
 
 import (
 	"context"
+	"log"
+	"slices"
+	"time"
 
 	"chanterelle/internal/repositories"
 )
 
+const (
+	DefaultContactPageSize = 50
+	MaxContactPageSize     = 200
+)
+
 type ContactService struct {
 	repository repositories.ContactRepository
+	people     repositories.PersonRepository
 }
 
-func NewContactService(repository repositories.ContactRepository) *ContactService {
+func NewContactService(repository repositories.ContactRepository, people repositories.PersonRepository) *ContactService {
 	return &ContactService{
 		repository: repository,
+		people:     people,
+	}
+}
+
+// CreateContact stores a submission and links it to the person with the
+// same normalized email. The person only counts the submission once it is
+// stored. If the person can't be recorded the contact stays unlinked and
+// gets linked later by LinkUnassignedContacts.
+func (s *ContactService) CreateContact(ctx context.Context, contact *repositories.Contact) error {
+	if err := s.repository.CreateContact(ctx, contact); err != nil {
+		return err
 	}
+
+	person, err := s.people.RecordSubmission(ctx, normalizeEmail(contact.Email), contact.Name, time.Now())
+	if err != nil {
+		log.Printf("Failed to record person for %s: %v", contact.Email, err)
+		return nil
+	}
+	if err := s.repository.SetContactPerson(ctx, contact.ID, person.ID); err != nil {
+		log.Printf("Failed to link contact %s to person %s: %v", contact.ID.Hex(), person.ID.Hex(), err)
+		if err := s.people.RemoveSubmissions(ctx, person.ID, 1); err != nil {
+			log.Printf("Failed to update person %s: %v", person.ID.Hex(), err)
+		}
+		return nil
+	}
+	contact.PersonID = &person.ID
+	return nil
 }
 
-func (s *ContactService) CreateContact(ctx context.Context, name, email, message string) error {
-	return s.repository.CreateContact(ctx, name, email, message)
+func (s *ContactService) ContactExistsByEmail(ctx context.Context, email string) (bool, error) {
+	return s.repository.ContactExistsByEmail(ctx, email)
 }
 
-func (s *ContactService) GetContacts(ctx context.Context) ([]repositories.Contact, error) {
-	return s.repository.GetContacts(ctx)
+func (s *ContactService) GetContacts(ctx context.Context, filter repositories.ContactFilter) (repositories.ContactPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultContactPageSize
+	}
+	if filter.Limit > MaxContactPageSize {
+		filter.Limit = MaxContactPageSize
+	}
+	return s.repository.GetContacts(ctx, filter)
 }
 
 func (s *ContactService) GetContactByID(ctx context.Context, id string) (repositories.Contact, error) {
 	return s.repository.GetContactByID(ctx, id)
 }
 
-func (s *ContactService) UpdateContact(ctx context.Context, id string, name, email, message string) error {
-	return s.repository.UpdateContact(ctx, id, name, email, message)
+// UpdateContact applies a partial update. A contact whose email changes to
+// an address of someone else is moved to that person.
+func (s *ContactService) UpdateContact(ctx context.Context, id string, update repositories.ContactUpdate) (repositories.Contact, error) {
+	contact, err := s.repository.UpdateContact(ctx, id, update)
+	if err != nil || update.Email == nil {
+		return contact, err
+	}
+	return s.relinkPerson(ctx, contact), nil
+}
+
+// relinkPerson links a contact to the person using its current email,
+// moving its submission count over from the person it was linked to.
+// Failures are logged rather than returned, as the contact itself is
+// already updated.
+func (s *ContactService) relinkPerson(ctx context.Context, contact repositories.Contact) repositories.Contact {
+	email := normalizeEmail(contact.Email)
+	if contact.PersonID != nil {
+		current, err := s.people.GetPersonByID(ctx, contact.PersonID.Hex())
+		if err == nil && slices.Contains(current.Emails, email) {
+			return contact
+		}
+	}
+
+	person, err := s.people.RecordSubmission(ctx, email, contact.Name, contact.CreatedAt)
+	if err != nil {
+		log.Printf("Failed to record person for contact %s: %v", contact.ID.Hex(), err)
+		return contact
+	}
+	if err := s.repository.SetContactPerson(ctx, contact.ID, person.ID); err != nil {
+		log.Printf("Failed to link contact %s to person %s: %v", contact.ID.Hex(), person.ID.Hex(), err)
+		return contact
+	}
+	if contact.PersonID != nil {
+		if err := s.people.RemoveSubmissions(ctx, *contact.PersonID, 1); err != nil {
+			log.Printf("Failed to update person %s: %v", contact.PersonID.Hex(), err)
+		}
+	}
+	contact.PersonID = &person.ID
+	return contact
+}
+
+func (s *ContactService) AddContactReply(ctx context.Context, id string, reply repositories.ContactReply, change *repositories.StatusChange) (repositories.Contact, error) {
+	return s.repository.AddContactReply(ctx, id, reply, change)
 }
 
-func (s *ContactService) DeleteContact(ctx context.Context, id string) error {
-	return s.repository.DeleteContact(ctx, id)
+func (s *ContactService) DeleteContact(ctx context.Context, id, deletedBy string) error {
+	return s.repository.DeleteContact(ctx, id, deletedBy)
 }
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chanterelle/internal/repositories"
+)
+
+var (
+	ErrUnknownContactStatus    = errors.New("unknown contact status")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// contactStatusTransitions lists the statuses each status may move to.
+var contactStatusTransitions = map[repositories.ContactStatus][]repositories.ContactStatus{
+	repositories.ContactStatusNew: {
+		repositories.ContactStatusRead,
+		repositories.ContactStatusReplied,
+		repositories.ContactStatusArchived,
+		repositories.ContactStatusSpam,
+	},
+	repositories.ContactStatusRead: {
+		repositories.ContactStatusNew,
+		repositories.ContactStatusReplied,
+		repositories.ContactStatusArchived,
+		repositories.ContactStatusSpam,
+	},
+	repositories.ContactStatusReplied: {
+		repositories.ContactStatusRead,
+		repositories.ContactStatusArchived,
+	},
+	repositories.ContactStatusArchived: {
+		repositories.ContactStatusNew,
+		repositories.ContactStatusRead,
+	},
+	repositories.ContactStatusSpam: {
+		repositories.ContactStatusNew,
+		repositories.ContactStatusArchived,
+	},
+}
+
+// CanTransition reports whether a contact in status from may be moved to
+// status to.
+func CanTransition(from, to repositories.ContactStatus) bool {
+	for _, allowed := range contactStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeContactStatus moves a contact to a new status on behalf of an admin
+// and records the change in its history.
+func (s *ContactService) ChangeContactStatus(ctx context.Context, id string, status repositories.ContactStatus, changedBy string) (repositories.Contact, error) {
+	if !status.IsValid() {
+		return repositories.Contact{}, fmt.Errorf("%w: %s", ErrUnknownContactStatus, status)
+	}
+
+	contact, err := s.repository.GetContactByID(ctx, id)
+	if err != nil {
+		return repositories.Contact{}, err
+	}
+
+	if contact.Status == status {
+		return contact, nil
+	}
+	if !CanTransition(contact.Status, status) {
+		return repositories.Contact{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, contact.Status, status)
+	}
+
+	return s.repository.UpdateContactStatus(ctx, id, repositories.StatusChange{
+		From:      contact.Status,
+		To:        status,
+		ChangedBy: changedBy,
+		ChangedAt: time.Now(),
+	})
+}
+
+func (s *ContactService) CountContactsByStatus(ctx context.Context) (map[repositories.ContactStatus]int64, error) {
+	return s.repository.CountContactsByStatus(ctx)
+}
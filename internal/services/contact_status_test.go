@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to repositories.ContactStatus
+		want     bool
+	}{
+		{repositories.ContactStatusNew, repositories.ContactStatusRead, true},
+		{repositories.ContactStatusNew, repositories.ContactStatusSpam, true},
+		{repositories.ContactStatusRead, repositories.ContactStatusNew, true},
+		{repositories.ContactStatusReplied, repositories.ContactStatusArchived, true},
+		{repositories.ContactStatusReplied, repositories.ContactStatusNew, false},
+		{repositories.ContactStatusReplied, repositories.ContactStatusSpam, false},
+		{repositories.ContactStatusArchived, repositories.ContactStatusReplied, false},
+		{repositories.ContactStatusSpam, repositories.ContactStatusNew, true},
+		{repositories.ContactStatusSpam, repositories.ContactStatusRead, false},
+		{repositories.ContactStatusNew, repositories.ContactStatusNew, false},
+		{repositories.ContactStatusNew, "pending", false},
+		{"pending", repositories.ContactStatusNew, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CanTransition(tt.from, tt.to), "%s to %s", tt.from, tt.to)
+	}
+}
+
+func TestContactStatusTransitionsCoverEveryStatus(t *testing.T) {
+	for _, status := range repositories.ContactStatuses {
+		targets, ok := contactStatusTransitions[status]
+		if assert.True(t, ok, "no transitions from %s", status) {
+			assert.NotEmpty(t, targets, status)
+		}
+		for _, target := range targets {
+			assert.True(t, target.IsValid(), "%s to %s", status, target)
+			assert.NotEqual(t, status, target)
+		}
+	}
+	assert.Len(t, contactStatusTransitions, len(repositories.ContactStatuses))
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"chanterelle/internal/repositories"
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+const (
+	maxTagLength     = 50
+	DefaultTagsLimit = 10
+	MaxTagsLimit     = 50
+)
+
+// NormalizeTag lower-cases a tag and collapses its whitespace so that
+// "Venue:  Higher Ground" and "venue: higher ground" are the same tag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if tag == "" {
+		return "", fmt.Errorf("%w: tag is empty", ErrInvalidTag)
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTag, tag, maxTagLength)
+	}
+	return tag, nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, t)
+	}
+	return normalized, nil
+}
+
+func (s *ContactService) AddContactTags(ctx context.Context, id string, tags []string) (repositories.Contact, error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return repositories.Contact{}, err
+	}
+	return s.repository.AddContactTags(ctx, id, normalized)
+}
+
+func (s *ContactService) RemoveContactTag(ctx context.Context, id, tag string) (repositories.Contact, error) {
+	normalized, err := NormalizeTag(tag)
+	if err != nil {
+		return repositories.Contact{}, err
+	}
+	return s.repository.RemoveContactTag(ctx, id, normalized)
+}
+
+// SuggestTags lists existing tags starting with prefix for autocomplete,
+// most used first.
+func (s *ContactService) SuggestTags(ctx context.Context, prefix string, limit int) ([]repositories.TagCount, error) {
+	if limit <= 0 {
+		limit = DefaultTagsLimit
+	}
+	if limit > MaxTagsLimit {
+		limit = MaxTagsLimit
+	}
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	return s.repository.GetTags(ctx, prefix, limit)
+}
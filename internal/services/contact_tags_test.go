@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"VIP", "vip"},
+		{"  Venue:  Higher\tGround ", "venue: higher ground"},
+		{"Été", "été"},
+		{strings.Repeat("é", maxTagLength), strings.Repeat("é", maxTagLength)},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.tag)
+		if assert.NoError(t, err, tt.tag) {
+			assert.Equal(t, tt.want, got)
+		}
+	}
+
+	for _, tag := range []string{"", "   ", strings.Repeat("a", maxTagLength+1)} {
+		_, err := NormalizeTag(tag)
+		assert.ErrorIs(t, err, ErrInvalidTag, tag)
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	// mailchimpSyncBatchSize caps the syncs processed in one worker pass.
+	mailchimpSyncBatchSize = 50
+	// mailchimpSyncLease is how long a claimed sync is hidden from other
+	// workers while it is processed.
+	mailchimpSyncLease = 5 * time.Minute
+	mailchimpRetryBase = time.Minute
+	mailchimpRetryMax  = 6 * time.Hour
+)
+
+// MailchimpSyncService subscribes contacts to Mailchimp in the background
+// so a slow or failing Mailchimp never fails a form submission. Each
+// contact's sync status, attempts and last error are stored on the contact.
+type MailchimpSyncService struct {
+	repository          repositories.ContactRepository
+	notificationService *NotificationService
+	maxAttempts         int
+}
+
+func NewMailchimpSyncService(repository repositories.ContactRepository, notificationService *NotificationService, cfg *config.Config) *MailchimpSyncService {
+	return &MailchimpSyncService{
+		repository:          repository,
+		notificationService: notificationService,
+		maxAttempts:         cfg.MailchimpSyncMaxAttempts,
+	}
+}
+
+// Queue marks a contact to be subscribed by the next worker pass.
+func (s *MailchimpSyncService) Queue(ctx context.Context, contactID primitive.ObjectID) error {
+	now := time.Now()
+	return s.repository.SetMailchimpSync(ctx, contactID, repositories.MailchimpSync{
+		Status:        repositories.MailchimpSyncPending,
+		NextAttemptAt: &now,
+	})
+}
+
+// QueueUnlessSynced queues a contact unless it is already queued or
+// subscribed, so it can safely be called again after a failure.
+func (s *MailchimpSyncService) QueueUnlessSynced(ctx context.Context, contactID primitive.ObjectID) error {
+	contact, err := s.repository.GetContactByID(ctx, contactID.Hex())
+	if err != nil {
+		return err
+	}
+	if state := contact.MailchimpSync; state != nil && state.Status != repositories.MailchimpSyncFailed {
+		return nil
+	}
+	return s.Queue(ctx, contactID)
+}
+
+// Retry queues a contact again with a fresh set of attempts, typically after
+// its sync failed.
+func (s *MailchimpSyncService) Retry(ctx context.Context, id string) (repositories.Contact, error) {
+	contact, err := s.repository.GetContactByID(ctx, id)
+	if err != nil {
+		return repositories.Contact{}, err
+	}
+	if err := s.Queue(ctx, contact.ID); err != nil {
+		return repositories.Contact{}, err
+	}
+	return s.repository.GetContactByID(ctx, id)
+}
+
+func (s *MailchimpSyncService) CountSyncs(ctx context.Context) (map[repositories.MailchimpSyncStatus]int64, error) {
+	return s.repository.CountMailchimpSyncs(ctx)
+}
+
+// SyncDue processes pending syncs that are due and returns how many were
+// attempted. A sync whose outcome can't be recorded is logged and left to
+// be claimed again when its lease runs out; only failing to claim stops
+// the pass.
+func (s *MailchimpSyncService) SyncDue(ctx context.Context) (int, error) {
+	for processed := 0; processed < mailchimpSyncBatchSize; processed++ {
+		now := time.Now()
+		contact, err := s.repository.ClaimMailchimpSync(ctx, now, now.Add(mailchimpSyncLease))
+		if errors.Is(err, repositories.ErrContactNotFound) {
+			return processed, nil
+		}
+		if err != nil {
+			return processed, err
+		}
+		if err := s.sync(ctx, contact); err != nil {
+			log.Printf("Failed to record Mailchimp sync for contact %s: %v", contact.ID.Hex(), err)
+		}
+	}
+	return mailchimpSyncBatchSize, nil
+}
+
+// sync makes one attempt and records the outcome.
+func (s *MailchimpSyncService) sync(ctx context.Context, contact repositories.Contact) error {
+	state := repositories.MailchimpSync{Status: repositories.MailchimpSyncPending}
+	if contact.MailchimpSync != nil {
+		state = *contact.MailchimpSync
+	}
+
+	now := time.Now()
+	state.Attempts++
+	state.LastAttemptAt = &now
+
+	err := s.notificationService.AddToMailchimp(&models.Contact{
+		Name:  contact.Name,
+		Email: contact.Email,
+	}, mailchimpTags(contact)...)
+	switch {
+	case err == nil:
+		state.Status = repositories.MailchimpSyncSynced
+		state.LastError = ""
+		state.NextAttemptAt = nil
+		state.SyncedAt = &now
+		log.Printf("Synced contact %s to Mailchimp", contact.ID.Hex())
+	case state.Attempts >= s.maxAttempts:
+		state.Status = repositories.MailchimpSyncFailed
+		state.LastError = err.Error()
+		state.NextAttemptAt = nil
+		log.Printf("Giving up on Mailchimp sync for contact %s after %d attempts: %v", contact.ID.Hex(), state.Attempts, err)
+	default:
+		next := now.Add(mailchimpRetryDelay(state.Attempts))
+		state.LastError = err.Error()
+		state.NextAttemptAt = &next
+	}
+
+	return s.repository.SetMailchimpSync(ctx, contact.ID, state)
+}
+
+// mailchimpTags labels a member with how and why they got in touch, so the
+// list can be segmented by form category and source.
+func mailchimpTags(contact repositories.Contact) []string {
+	var tags []string
+	if contact.Category != "" {
+		tags = append(tags, "category:"+string(contact.Category))
+	}
+	if contact.Source != "" {
+		tags = append(tags, "source:"+string(contact.Source))
+	}
+	return tags
+}
+
+// mailchimpRetryDelay doubles the wait after each failed attempt.
+func mailchimpRetryDelay(attempts int) time.Duration {
+	delay := mailchimpRetryBase
+	for i := 1; i < attempts && delay < mailchimpRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > mailchimpRetryMax {
+		delay = mailchimpRetryMax
+	}
+	return delay
+}
+
+// RunMailchimpSyncWorker processes due syncs every interval until ctx is
+// cancelled.
+func (s *MailchimpSyncService) RunMailchimpSyncWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SyncDue(ctx); err != nil {
+			log.Printf("Failed to sync contacts to Mailchimp: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryContactRepository) find(id primitive.ObjectID) *repositories.Contact {
+	for _, contact := range r.contacts {
+		if contact.ID == id {
+			return contact
+		}
+	}
+	return nil
+}
+
+func (r *memoryContactRepository) GetContactByID(_ context.Context, id string) (repositories.Contact, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return repositories.Contact{}, repositories.ErrContactNotFound
+	}
+	contact := r.find(objID)
+	if contact == nil {
+		return repositories.Contact{}, repositories.ErrContactNotFound
+	}
+	return *contact, nil
+}
+
+func (r *memoryContactRepository) SetMailchimpSync(_ context.Context, id primitive.ObjectID, state repositories.MailchimpSync) error {
+	contact := r.find(id)
+	if contact == nil {
+		return repositories.ErrContactNotFound
+	}
+	contact.MailchimpSync = &state
+	return nil
+}
+
+func (r *memoryContactRepository) ClaimMailchimpSync(_ context.Context, now, leaseUntil time.Time) (repositories.Contact, error) {
+	for _, contact := range r.contacts {
+		state := contact.MailchimpSync
+		if state == nil || state.Status != repositories.MailchimpSyncPending || state.NextAttemptAt == nil || state.NextAttemptAt.After(now) {
+			continue
+		}
+		claimed := *contact
+		lease := leaseUntil
+		state.NextAttemptAt = &lease
+		return claimed, nil
+	}
+	return repositories.Contact{}, repositories.ErrContactNotFound
+}
+
+// mailchimpStub is a fake Mailchimp API that fails while failing is set.
+type mailchimpStub struct {
+	mu      sync.Mutex
+	failing bool
+	upserts int
+	deletes int
+}
+
+func (m *mailchimpStub) setFailing(failing bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failing = failing
+}
+
+func (m *mailchimpStub) upserted() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.upserts
+}
+
+func (m *mailchimpStub) deleted() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deletes
+}
+
+// newTestMailchimp points a NotificationService's Mailchimp calls at a stub.
+func newTestMailchimp(t *testing.T, s *NotificationService) *mailchimpStub {
+	stub := &mailchimpStub{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		if stub.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPut {
+			stub.upserts++
+			w.Write([]byte(`{"status":"subscribed"}`))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			stub.deletes++
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	s.cfg.MailchimpListID = "test-list"
+	s.mailchimpBaseURL = server.URL
+	return stub
+}
+
+func TestMailchimpRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{1000, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mailchimpRetryDelay(tt.attempts), tt.attempts)
+	}
+}
+
+func TestMailchimpSyncRetriesThenGivesUp(t *testing.T) {
+	ctx := context.Background()
+	contact := &repositories.Contact{ID: primitive.NewObjectID(), Name: "Pat Fan", Email: "fan@example.com"}
+	contacts := &memoryContactRepository{contacts: []*repositories.Contact{contact}}
+	notifications := NewNotificationService(&config.Config{})
+	mailchimp := newTestMailchimp(t, notifications)
+	mailchimp.setFailing(true)
+	s := NewMailchimpSyncService(contacts, notifications, &config.Config{MailchimpSyncMaxAttempts: 3})
+
+	require.NoError(t, s.Queue(ctx, contact.ID))
+
+	// Makes the next attempt due now, as if its backoff had passed.
+	makeDue := func() {
+		past := time.Now().Add(-time.Second)
+		contact.MailchimpSync.NextAttemptAt = &past
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		processed, err := s.SyncDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+
+		state := contact.MailchimpSync
+		assert.Equal(t, repositories.MailchimpSyncPending, state.Status)
+		assert.Equal(t, attempt, state.Attempts)
+		assert.Contains(t, state.LastError, "503")
+		require.NotNil(t, state.NextAttemptAt)
+		assert.WithinDuration(t, before.Add(mailchimpRetryDelay(attempt)), *state.NextAttemptAt, time.Second)
+
+		// Nothing is retried before its backoff is over.
+		processed, err = s.SyncDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, processed)
+		makeDue()
+	}
+
+	processed, err := s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	state := contact.MailchimpSync
+	assert.Equal(t, repositories.MailchimpSyncFailed, state.Status)
+	assert.Equal(t, 3, state.Attempts)
+	assert.Nil(t, state.NextAttemptAt)
+	assert.NotEmpty(t, state.LastError)
+
+	// A failed sync stays failed until an admin retries it.
+	processed, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+
+	mailchimp.setFailing(false)
+	retried, err := s.Retry(ctx, contact.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, repositories.MailchimpSyncPending, retried.MailchimpSync.Status)
+	assert.Zero(t, retried.MailchimpSync.Attempts)
+
+	processed, err = s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	state = contact.MailchimpSync
+	assert.Equal(t, repositories.MailchimpSyncSynced, state.Status)
+	assert.Equal(t, 1, state.Attempts)
+	assert.Empty(t, state.LastError)
+	assert.Nil(t, state.NextAttemptAt)
+	assert.NotNil(t, state.SyncedAt)
+	assert.Equal(t, 1, mailchimp.upserted())
+}
+
+// unrecordableSyncRepository fails to record the sync outcome of one contact,
+// as when it is purged while being synced.
+type unrecordableSyncRepository struct {
+	*memoryContactRepository
+	failID primitive.ObjectID
+}
+
+func (r *unrecordableSyncRepository) SetMailchimpSync(ctx context.Context, id primitive.ObjectID, state repositories.MailchimpSync) error {
+	if id == r.failID && state.Attempts > 0 {
+		return repositories.ErrContactNotFound
+	}
+	return r.memoryContactRepository.SetMailchimpSync(ctx, id, state)
+}
+
+func TestSyncDueContinuesPastUnrecordableContact(t *testing.T) {
+	ctx := context.Background()
+	first := &repositories.Contact{ID: primitive.NewObjectID(), Name: "Pat Fan", Email: "fan@example.com"}
+	second := &repositories.Contact{ID: primitive.NewObjectID(), Name: "Sam Fan", Email: "sam@example.com"}
+	contacts := &unrecordableSyncRepository{
+		memoryContactRepository: &memoryContactRepository{contacts: []*repositories.Contact{first, second}},
+		failID:                  first.ID,
+	}
+	notifications := NewNotificationService(&config.Config{})
+	mailchimp := newTestMailchimp(t, notifications)
+	s := NewMailchimpSyncService(contacts, notifications, &config.Config{MailchimpSyncMaxAttempts: 3})
+
+	require.NoError(t, s.Queue(ctx, first.ID))
+	require.NoError(t, s.Queue(ctx, second.ID))
+
+	processed, err := s.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	assert.Equal(t, repositories.MailchimpSyncPending, first.MailchimpSync.Status)
+	assert.Equal(t, repositories.MailchimpSyncSynced, second.MailchimpSync.Status)
+	assert.Equal(t, 2, mailchimp.upserted())
+}
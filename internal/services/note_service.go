@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+)
+
+var (
+	ErrEmptyNote     = errors.New("note body is required")
+	ErrInvalidParent = errors.New("parent note belongs to a different contact")
+)
+
+// NoteThread is a note with its replies nested underneath it.
+type NoteThread struct {
+	repositories.Note
+	Replies []*NoteThread
+}
+
+type NoteService struct {
+	notes    repositories.NoteRepository
+	contacts repositories.ContactRepository
+}
+
+func NewNoteService(notes repositories.NoteRepository, contacts repositories.ContactRepository) *NoteService {
+	return &NoteService{
+		notes:    notes,
+		contacts: contacts,
+	}
+}
+
+// AddNote adds an internal note to a contact. A parentID makes the note a
+// reply in that note's thread.
+func (s *NoteService) AddNote(ctx context.Context, contactID, parentID, author, body string) (repositories.Note, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return repositories.Note{}, ErrEmptyNote
+	}
+
+	contact, err := s.contacts.GetContactByID(ctx, contactID)
+	if err != nil {
+		return repositories.Note{}, err
+	}
+
+	note := repositories.Note{
+		ContactID: contact.ID,
+		Author:    author,
+		Body:      body,
+	}
+
+	if parentID != "" {
+		parentObjID, err := primitive.ObjectIDFromHex(parentID)
+		if err != nil {
+			return repositories.Note{}, repositories.ErrNoteNotFound
+		}
+		parent, err := s.notes.GetNoteByID(ctx, parentObjID)
+		if err != nil {
+			return repositories.Note{}, err
+		}
+		if parent.ContactID != contact.ID {
+			return repositories.Note{}, ErrInvalidParent
+		}
+		note.ParentID = &parent.ID
+	}
+
+	if err := s.notes.CreateNote(ctx, &note); err != nil {
+		return repositories.Note{}, err
+	}
+	return note, nil
+}
+
+// GetNoteThreads returns a contact's notes as threads, oldest first.
+func (s *NoteService) GetNoteThreads(ctx context.Context, contactID string) ([]*NoteThread, error) {
+	contact, err := s.contacts.GetContactByID(ctx, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.notes.GetNotesByContactID(ctx, contact.ID)
+	if err != nil {
+		return nil, err
+	}
+	return buildNoteThreads(notes), nil
+}
+
+// buildNoteThreads nests notes under their parents. Notes arrive oldest
+// first, so each reply is appended after the replies before it. Replies
+// whose parent is missing are shown at the top level.
+func buildNoteThreads(notes []repositories.Note) []*NoteThread {
+	byID := make(map[primitive.ObjectID]*NoteThread, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = &NoteThread{Note: note, Replies: []*NoteThread{}}
+	}
+
+	threads := []*NoteThread{}
+	for _, note := range notes {
+		thread := byID[note.ID]
+		if note.ParentID != nil {
+			if parent, ok := byID[*note.ParentID]; ok {
+				parent.Replies = append(parent.Replies, thread)
+				continue
+			}
+		}
+		threads = append(threads, thread)
+	}
+	return threads
+}
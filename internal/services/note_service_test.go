@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryNoteRepository is an in-memory NoteRepository for tests.
+type memoryNoteRepository struct {
+	repositories.NoteRepository
+	notes []repositories.Note
+}
+
+func (r *memoryNoteRepository) DeleteNotesByContactIDs(_ context.Context, contactIDs []primitive.ObjectID) (int64, error) {
+	var kept []repositories.Note
+	var deleted int64
+	for _, note := range r.notes {
+		remove := false
+		for _, id := range contactIDs {
+			if note.ContactID == id {
+				remove = true
+			}
+		}
+		if remove {
+			deleted++
+		} else {
+			kept = append(kept, note)
+		}
+	}
+	r.notes = kept
+	return deleted, nil
+}
+
+func TestBuildNoteThreads(t *testing.T) {
+	contactID := primitive.NewObjectID()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	note := func(minute int, parent *repositories.Note) repositories.Note {
+		n := repositories.Note{
+			ID:        primitive.NewObjectID(),
+			ContactID: contactID,
+			Body:      "note",
+			CreatedAt: start.Add(time.Duration(minute) * time.Minute),
+		}
+		if parent != nil {
+			n.ParentID = &parent.ID
+		}
+		return n
+	}
+
+	first := note(0, nil)
+	second := note(1, nil)
+	reply := note(2, &first)
+	nested := note(3, &reply)
+	secondReply := note(4, &first)
+	missing := repositories.Note{ID: primitive.NewObjectID()}
+	orphan := note(5, &missing)
+
+	threads := buildNoteThreads([]repositories.Note{first, second, reply, nested, secondReply, orphan})
+	require.Len(t, threads, 3)
+	assert.Equal(t, first.ID, threads[0].ID)
+	assert.Equal(t, second.ID, threads[1].ID)
+	// A reply whose parent is gone is shown at the top level.
+	assert.Equal(t, orphan.ID, threads[2].ID)
+
+	require.Len(t, threads[0].Replies, 2)
+	assert.Equal(t, reply.ID, threads[0].Replies[0].ID)
+	assert.Equal(t, secondReply.ID, threads[0].Replies[1].ID)
+	require.Len(t, threads[0].Replies[0].Replies, 1)
+	assert.Equal(t, nested.ID, threads[0].Replies[0].Replies[0].ID)
+
+	// Notes without replies have an empty list rather than nil, so they
+	// encode as [].
+	assert.NotNil(t, threads[1].Replies)
+	assert.Empty(t, threads[1].Replies)
+
+	assert.Empty(t, buildNoteThreads(nil))
+	assert.NotNil(t, buildNoteThreads(nil))
+}
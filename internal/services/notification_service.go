@@ -4,19 +4,28 @@ import (
 	"bytes"
 	"chanterelle/internal/config"
 	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+	"crypto/md5"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type NotificationService struct {
 	cfg    *config.Config
 	client *http.Client
+	// mailchimpBaseURL overrides the API root derived from the API key's
+	// datacenter, for tests.
+	mailchimpBaseURL string
+	// emailJSURL overrides the EmailJS send endpoint, for tests.
+	emailJSURL string
 }
 
 type emailJSParams struct {
@@ -46,64 +55,143 @@ func NewNotificationService(cfg *config.Config) *NotificationService {
 	}
 }
 
-func (s *NotificationService) AddToMailchimp(contact *models.Contact) error {
-	// Mailchimp API endpoint
-	datacenter := strings.Split(s.cfg.MailchimpAPIKey, "-")[1]
-	endpoint := fmt.Sprintf("https://%s.api.mailchimp.com/3.0/lists/%s/members", datacenter, s.cfg.MailchimpListID)
+// AddToMailchimp adds or updates the contact on the Mailchimp list. Members
+// are upserted by subscriber hash, so writing in again is never an error.
+// New members are subscribed, while existing members keep their status: an
+// unsubscribed fan is not resubscribed. The name is stored in the FNAME and
+// LNAME merge fields, and any tags are added to the member.
+func (s *NotificationService) AddToMailchimp(contact *models.Contact, tags ...string) error {
+	memberURL, err := s.mailchimpMemberURL(contact.Email)
+	if err != nil {
+		return err
+	}
+
+	member := map[string]interface{}{
+		"email_address": strings.TrimSpace(contact.Email),
+		"status_if_new": "subscribed",
+	}
+	if firstName, lastName := splitName(contact.Name); firstName != "" {
+		member["merge_fields"] = map[string]string{
+			"FNAME": firstName,
+			"LNAME": lastName,
+		}
+	}
+
+	if err := s.mailchimpRequest(http.MethodPut, memberURL, member, nil); err != nil {
+		return err
+	}
 
-	// Prepare Mailchimp subscription data
-	data := map[string]interface{}{
-		"email_address": contact.Email,
-		"name":          contact.Name,
-		"status":        "subscribed",
+	if len(tags) == 0 {
+		return nil
 	}
+	memberTags := make([]map[string]string, len(tags))
+	for i, tag := range tags {
+		memberTags[i] = map[string]string{"name": tag, "status": "active"}
+	}
+	return s.mailchimpRequest(http.MethodPost, memberURL+"/tags", map[string]interface{}{"tags": memberTags}, nil)
+}
 
-	// Convert to JSON
-	jsonData, err := json.Marshal(data)
+// ArchiveMailchimpMember archives the list member for email, so they are no
+// longer emailed and their details are dropped from the audience. A member
+// that doesn't exist is already gone.
+func (s *NotificationService) ArchiveMailchimpMember(email string) error {
+	memberURL, err := s.mailchimpMemberURL(email)
 	if err != nil {
-		return fmt.Errorf("failed to marshal mailchimp data: %v", err)
+		return err
+	}
+
+	err = s.mailchimpRequest(http.MethodDelete, memberURL, nil, nil)
+	var apiErr *mailchimpAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
 	}
+	return err
+}
+
+// mailchimpAPIError is a non-2xx response from the Mailchimp API.
+type mailchimpAPIError struct {
+	StatusCode int
+	Body       string
+}
 
-	// Create request
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(string(jsonData)))
+func (e *mailchimpAPIError) Error() string {
+	return fmt.Sprintf("mailchimp API returned status: %d, error: %s", e.StatusCode, e.Body)
+}
+
+// mailchimpMemberURL is the API URL of a list member, keyed by the MD5 hash
+// of the lower-cased email as Mailchimp requires.
+func (s *NotificationService) mailchimpMemberURL(email string) (string, error) {
+	baseURL := s.mailchimpBaseURL
+	if baseURL == "" {
+		_, datacenter, ok := strings.Cut(s.cfg.MailchimpAPIKey, "-")
+		if !ok || datacenter == "" {
+			return "", fmt.Errorf("mailchimp API key has no datacenter suffix")
+		}
+		baseURL = fmt.Sprintf("https://%s.api.mailchimp.com/3.0", datacenter)
+	}
+
+	hash := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return fmt.Sprintf("%s/lists/%s/members/%s", baseURL, s.cfg.MailchimpListID, hex.EncodeToString(hash[:])), nil
+}
+
+// mailchimpRequest sends a JSON request to the Mailchimp API and decodes the
+// response into out when it is not nil.
+func (s *NotificationService) mailchimpRequest(method, endpoint string, payload, out interface{}) error {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal mailchimp data: %v", err)
+		}
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
-
-	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("anystring:"+s.cfg.MailchimpAPIKey)))
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send mailchimp request: %v", err)
 	}
 	defer resp.Body.Close()
 
-	// Read and log the response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %v", err)
 	}
-	log.Printf("Mailchimp response: Status=%d, Body=%s", resp.StatusCode, string(body))
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("mailchimp API returned status: %d, error: %s", resp.StatusCode, string(body))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &mailchimpAPIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode mailchimp response: %v", err)
+		}
+	}
 	return nil
 }
 
 func (s *NotificationService) sendEmailJS(params emailJSParams) error {
-	if s.cfg.EmailJSServiceID == "" || s.cfg.EmailJSTemplateID == "" || s.cfg.EmailJSUserID == "" || s.cfg.EmailJSAccessToken == "" {
+	return s.sendEmailJSTemplate(s.cfg.EmailJSTemplateID, params)
+}
+
+// sendEmailJSTemplate sends params through a specific EmailJS template,
+// falling back to the default template when templateID is empty.
+func (s *NotificationService) sendEmailJSTemplate(templateID string, params emailJSParams) error {
+	if templateID == "" {
+		templateID = s.cfg.EmailJSTemplateID
+	}
+	if s.cfg.EmailJSServiceID == "" || templateID == "" || s.cfg.EmailJSUserID == "" || s.cfg.EmailJSAccessToken == "" {
 		return fmt.Errorf("emailjs configuration is not complete")
 	}
 
 	reqBody := emailJSRequest{
 		ServiceID:      s.cfg.EmailJSServiceID,
-		TemplateID:     s.cfg.EmailJSTemplateID,
+		TemplateID:     templateID,
 		UserID:         s.cfg.EmailJSUserID,
 		AccessToken:    s.cfg.EmailJSAccessToken,
 		TemplateParams: params,
@@ -114,9 +202,14 @@ func (s *NotificationService) sendEmailJS(params emailJSParams) error {
 		return fmt.Errorf("failed to marshal emailjs request: %v", err)
 	}
 
+	sendURL := "https://api.emailjs.com/api/v1.0/email/send"
+	if s.emailJSURL != "" {
+		sendURL = s.emailJSURL
+	}
+
 	// Send request to EmailJS
 	resp, err := s.client.Post(
-		"https://api.emailjs.com/api/v1.0/email/send",
+		sendURL,
 		"application/json",
 		bytes.NewBuffer(jsonData),
 	)
@@ -146,16 +239,27 @@ func (s *NotificationService) SendVerificationCode(email, code string) error {
 	return s.sendEmailJS(params)
 }
 
-func (s *NotificationService) SendNewContactNotification(contact *models.Contact) error {
-	// First name and last name handling (assuming Name is in format "First Last")
-	nameParts := strings.Fields(contact.Name)
-	firstName := contact.Name
-	lastName := ""
-	if len(nameParts) > 1 {
-		firstName = strings.Join(nameParts[:len(nameParts)-1], " ")
-		lastName = nameParts[len(nameParts)-1]
+// SendAdminInvite tells someone they've been given admin access. Like
+// verification codes, it is addressed to {{email}}.
+func (s *NotificationService) SendAdminInvite(email, role, invitedBy, loginURL string) error {
+	message := fmt.Sprintf("%s has given you %s access to the Chanterelle admin area. Sign in with this email address to get started.", invitedBy, role)
+	if loginURL != "" {
+		message += " " + loginURL
+	}
+
+	params := emailJSParams{
+		ToName:      email,
+		Destination: "You've been invited to the Chanterelle admin area",
+		Email:       email,
+		Message:     message,
 	}
 
+	return s.sendEmailJS(params)
+}
+
+func (s *NotificationService) SendNewContactNotification(contact *models.Contact) error {
+	firstName, lastName := splitName(contact.Name)
+
 	params := emailJSParams{
 		ToName:      "Chanterelle member",
 		Destination: "New Contact Form Submission",
@@ -167,3 +271,159 @@ func (s *NotificationService) SendNewContactNotification(contact *models.Contact
 
 	return s.sendEmailJS(params)
 }
+
+// SendSubscriptionConfirmation emails the double opt-in confirmation link.
+// The template (EMAILJS_CONFIRM_TEMPLATE_ID) must address the message to
+// {{email}}.
+func (s *NotificationService) SendSubscriptionConfirmation(contact *models.Contact, link string, expiresAt time.Time) error {
+	firstName, lastName := splitName(contact.Name)
+
+	params := emailJSParams{
+		ToName:      contact.Name,
+		Destination: "Please confirm your subscription",
+		Firstname:   firstName,
+		Lastname:    lastName,
+		Email:       contact.Email,
+		Message: fmt.Sprintf("Confirm that you'd like to join the Chanterelle mailing list: %s\n\nThis link expires on %s. If you didn't sign up, you can ignore this email.",
+			link, expiresAt.UTC().Format("January 2, 2006 at 15:04 UTC")),
+	}
+
+	return s.sendEmailJSTemplate(s.cfg.EmailJSConfirmTemplateID, params)
+}
+
+// SendPrivacyRequestLink emails the link that completes a self-service
+// export or erasure request. Like confirmation emails, the template
+// (EMAILJS_CONFIRM_TEMPLATE_ID) must address the message to {{email}}.
+func (s *NotificationService) SendPrivacyRequestLink(email, action, link string, expiresAt time.Time) error {
+	request := "a copy of the data we hold about you"
+	if action == "erase" {
+		request = "that we delete the data we hold about you"
+	}
+
+	params := emailJSParams{
+		ToName:      email,
+		Destination: "Confirm your privacy request",
+		Email:       email,
+		Message: fmt.Sprintf("Someone asked for %s. To continue, follow this link: %s\n\nThis link expires on %s. If you didn't ask for this, you can ignore this email.",
+			request, link, expiresAt.UTC().Format("January 2, 2006 at 15:04 UTC")),
+	}
+
+	return s.sendEmailJSTemplate(s.cfg.EmailJSConfirmTemplateID, params)
+}
+
+// SendAutoReply acknowledges a form submission using templateID, which must
+// address the message to {{email}}. Anyone can submit the form with someone
+// else's address, so the submitted message is never included and the name
+// is only used when it is short and free of links.
+func (s *NotificationService) SendAutoReply(contact *models.Contact, templateID string) error {
+	name := autoReplyName(contact.Name)
+	firstName, lastName := splitName(name)
+
+	params := emailJSParams{
+		ToName:      name,
+		Destination: "Thanks for getting in touch",
+		Firstname:   firstName,
+		Lastname:    lastName,
+		Email:       contact.Email,
+	}
+
+	return s.sendEmailJSTemplate(templateID, params)
+}
+
+// SendRoutedContactNotification tells recipient about a new contact in a
+// category, as decided by the category's routing rule. Like verification
+// codes, it is addressed to {{email}}, with the sender's details in the
+// message.
+func (s *NotificationService) SendRoutedContactNotification(contact *models.Contact, category, recipient string) error {
+	firstName, lastName := splitName(contact.Name)
+
+	params := emailJSParams{
+		ToName:      "Chanterelle member",
+		Destination: fmt.Sprintf("New %s message from %s", strings.ReplaceAll(category, "_", " "), contact.Name),
+		Firstname:   firstName,
+		Lastname:    lastName,
+		Email:       recipient,
+		Message:     fmt.Sprintf("From: %s <%s>\n\n%s", contact.Name, contact.Email, contact.Message),
+	}
+
+	return s.sendEmailJS(params)
+}
+
+// SendBookingNotification tells the admin about a new booking inquiry. It
+// uses EMAILJS_BOOKING_TEMPLATE_ID when set and the default template
+// otherwise, with the inquiry details laid out in the message.
+func (s *NotificationService) SendBookingNotification(booking *repositories.Booking) error {
+	firstName, lastName := splitName(booking.Name)
+
+	details := []string{
+		"Event type: " + booking.EventType,
+		"Date: " + booking.EventDate.Format("Monday, January 2, 2006"),
+		"Venue: " + booking.Venue,
+		"City: " + booking.City,
+	}
+	if booking.Capacity > 0 {
+		details = append(details, fmt.Sprintf("Capacity: %d", booking.Capacity))
+	}
+	if booking.Budget != "" {
+		details = append(details, "Budget: "+booking.Budget)
+	}
+	if booking.Phone != "" {
+		details = append(details, "Phone: "+booking.Phone)
+	}
+	if booking.Message != "" {
+		details = append(details, "", booking.Message)
+	}
+
+	params := emailJSParams{
+		ToName:      "Chanterelle member",
+		Destination: fmt.Sprintf("New Booking Inquiry: %s, %s", booking.Venue, booking.EventDate.Format("Jan 2, 2006")),
+		Firstname:   firstName,
+		Lastname:    lastName,
+		Email:       booking.Email,
+		Message:     strings.Join(details, "\n"),
+	}
+
+	return s.sendEmailJSTemplate(s.cfg.EmailJSBookingTemplateID, params)
+}
+
+// SendContactReply emails an admin's reply to someone who wrote in. The
+// reply template (EMAILJS_REPLY_TEMPLATE_ID) must address the message to
+// {{email}}.
+func (s *NotificationService) SendContactReply(contact *models.Contact, subject, body string) error {
+	firstName, lastName := splitName(contact.Name)
+
+	params := emailJSParams{
+		ToName:      contact.Name,
+		Destination: subject,
+		Firstname:   firstName,
+		Lastname:    lastName,
+		Email:       contact.Email,
+		Message:     body,
+	}
+
+	return s.sendEmailJSTemplate(s.cfg.EmailJSReplyTemplateID, params)
+}
+
+// splitName splits a full name into first and last names, treating the last
+// word as the last name.
+func splitName(name string) (string, string) {
+	nameParts := strings.Fields(name)
+	if len(nameParts) < 2 {
+		return name, ""
+	}
+	return strings.Join(nameParts[:len(nameParts)-1], " "), nameParts[len(nameParts)-1]
+}
+
+// maxAutoReplyNameLength caps the name greeted in an auto-reply.
+const maxAutoReplyNameLength = 40
+
+// autoReplyName is the name to greet in an auto-reply, or "there" when the
+// submitted one could carry a message of its own.
+func autoReplyName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > maxAutoReplyNameLength ||
+		linkPattern.MatchString(name) || strings.ContainsAny(name, "@<>/:") {
+		return "there"
+	}
+	return name
+}
@@ -222,3 +222,47 @@ func TestEmailJSMock(t *testing.T) {
 		assert.Contains(t, err.Error(), "emailjs API returned status 500")
 	})
 }
+
+// TestMailchimpUpsertMock tests the member upsert against a mock Mailchimp API
+func TestMailchimpUpsertMock(t *testing.T) {
+	// md5 of "fan@example.com"
+	const memberPath = "/lists/test-list/members/b0215f672117e282a7b3bbcbb8157ba1"
+
+	var requests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.Method + " " + r.URL.Path {
+		case "PUT " + memberPath:
+			var member map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&member))
+			assert.Equal(t, "Fan@Example.com", member["email_address"])
+			assert.Equal(t, "subscribed", member["status_if_new"])
+			assert.NotContains(t, member, "status", "existing members keep their status")
+			assert.Equal(t, map[string]interface{}{"FNAME": "Pat", "LNAME": "Fan"}, member["merge_fields"])
+
+			json.NewEncoder(w).Encode(map[string]string{"status": "unsubscribed"})
+		case "POST " + memberPath + "/tags":
+			var body struct {
+				Tags []map[string]string `json:"tags"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, []map[string]string{{"name": "category:fan_mail", "status": "active"}}, body.Tags)
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	service := &NotificationService{
+		cfg:              &config.Config{MailchimpAPIKey: "key-us1", MailchimpListID: "test-list"},
+		client:           testServer.Client(),
+		mailchimpBaseURL: testServer.URL,
+	}
+
+	err := service.AddToMailchimp(&models.Contact{Name: "Pat Fan", Email: "Fan@Example.com"}, "category:fan_mail")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PUT " + memberPath, "POST " + memberPath + "/tags"}, requests)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+)
+
+var ErrMergeSamePerson = errors.New("cannot merge a person into themselves")
+
+const (
+	DefaultPeoplePageSize = 50
+	MaxPeoplePageSize     = 200
+)
+
+func (s *ContactService) GetPeople(ctx context.Context, filter repositories.PersonFilter) ([]repositories.Person, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPeoplePageSize
+	}
+	if filter.Limit > MaxPeoplePageSize {
+		filter.Limit = MaxPeoplePageSize
+	}
+	return s.people.GetPeople(ctx, filter)
+}
+
+func (s *ContactService) GetPerson(ctx context.Context, id string) (repositories.Person, error) {
+	return s.people.GetPersonByID(ctx, id)
+}
+
+// GetPersonTimeline lists every submission from a person, narrowed by the
+// usual listing filter.
+func (s *ContactService) GetPersonTimeline(ctx context.Context, id string, filter repositories.ContactFilter) (repositories.ContactPage, error) {
+	person, err := s.people.GetPersonByID(ctx, id)
+	if err != nil {
+		return repositories.ContactPage{}, err
+	}
+	filter.PersonID = &person.ID
+	return s.GetContacts(ctx, filter)
+}
+
+// MergePeople folds source into target for people who wrote in under
+// different spellings of their address. Every submission from source is
+// relinked to target in the same transaction as the merge, so the whole
+// message history ends up on one timeline.
+func (s *ContactService) MergePeople(ctx context.Context, targetID, sourceID string) (repositories.Person, error) {
+	if targetID == sourceID {
+		return repositories.Person{}, ErrMergeSamePerson
+	}
+
+	return s.people.MergePeople(ctx, targetID, sourceID, func(ctx context.Context, sourceID, targetID primitive.ObjectID) error {
+		_, err := s.repository.MoveContactsToPerson(ctx, sourceID, targetID)
+		return err
+	})
+}
+
+// LinkUnassignedContacts links contacts stored before people existed, or
+// whose person could not be recorded at submission time.
+func (s *ContactService) LinkUnassignedContacts(ctx context.Context) (int, error) {
+	linked := 0
+	err := s.repository.StreamContacts(ctx, repositories.ContactFilter{WithoutPerson: true, Ascending: true}, func(contact repositories.Contact) error {
+		person, err := s.people.RecordSubmission(ctx, normalizeEmail(contact.Email), contact.Name, contact.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.repository.SetContactPerson(ctx, contact.ID, person.ID); err != nil {
+			return err
+		}
+		linked++
+		return nil
+	})
+	if linked > 0 {
+		log.Printf("Linked %d contacts to people", linked)
+	}
+	return linked, err
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryContactRepository) MoveContactsToPerson(_ context.Context, fromPersonID, toPersonID primitive.ObjectID) (int64, error) {
+	var moved int64
+	for _, contact := range r.contacts {
+		if contact.PersonID != nil && *contact.PersonID == fromPersonID {
+			id := toPersonID
+			contact.PersonID = &id
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (r *memoryContactRepository) UpdateContact(_ context.Context, id string, update repositories.ContactUpdate) (repositories.Contact, error) {
+	for _, contact := range r.contacts {
+		if contact.ID.Hex() != id {
+			continue
+		}
+		if update.Email != nil {
+			contact.Email = *update.Email
+		}
+		return *contact, nil
+	}
+	return repositories.Contact{}, repositories.ErrContactNotFound
+}
+
+func (r *memoryContactRepository) SetContactPerson(_ context.Context, id, personID primitive.ObjectID) error {
+	for _, contact := range r.contacts {
+		if contact.ID == id {
+			contact.PersonID = &personID
+			return nil
+		}
+	}
+	return repositories.ErrContactNotFound
+}
+
+func (r *memoryPersonRepository) RemoveSubmissions(_ context.Context, id primitive.ObjectID, n int) error {
+	if person, ok := r.people[id]; ok {
+		person.MessageCount -= n
+	}
+	return nil
+}
+
+func (r *memoryPersonRepository) GetPersonByID(_ context.Context, id string) (repositories.Person, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return repositories.Person{}, err
+	}
+	person, ok := r.people[objID]
+	if !ok {
+		return repositories.Person{}, repositories.ErrPersonNotFound
+	}
+	return *person, nil
+}
+
+func (r *memoryPersonRepository) MergePeople(ctx context.Context, targetID, sourceID string, moveContacts func(ctx context.Context, sourceID, targetID primitive.ObjectID) error) (repositories.Person, error) {
+	target, err := r.GetPersonByID(context.Background(), targetID)
+	if err != nil {
+		return repositories.Person{}, err
+	}
+	source, err := r.GetPersonByID(context.Background(), sourceID)
+	if err != nil {
+		return repositories.Person{}, err
+	}
+	if r.beforeMerge != nil {
+		r.beforeMerge()
+	}
+
+	if err := moveContacts(ctx, source.ID, target.ID); err != nil {
+		return repositories.Person{}, err
+	}
+	delete(r.people, source.ID)
+	merged := r.people[target.ID]
+	merged.Emails = append(merged.Emails, source.Emails...)
+	merged.MessageCount += source.MessageCount
+	return *merged, nil
+}
+
+func TestMergePeopleRelinksEveryContact(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+	s := NewContactService(contacts, people)
+
+	for _, email := range []string{"alex@example.com", "alex.smith@example.com", "alex@example.com"} {
+		require.NoError(t, s.CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: email}))
+	}
+	target := *contacts.contacts[0].PersonID
+	source := *contacts.contacts[1].PersonID
+
+	// A submission that lands on the source while it is being merged is
+	// relinked too.
+	people.beforeMerge = func() {
+		contacts.contacts = append(contacts.contacts, &repositories.Contact{
+			ID:        primitive.NewObjectID(),
+			Email:     "alex.smith@example.com",
+			PersonID:  &source,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	merged, err := s.MergePeople(ctx, target.Hex(), source.Hex())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alex@example.com", "alex.smith@example.com"}, merged.Emails)
+	assert.Equal(t, 3, merged.MessageCount)
+
+	_, err = s.GetPerson(ctx, source.Hex())
+	assert.ErrorIs(t, err, repositories.ErrPersonNotFound)
+	require.Len(t, contacts.contacts, 4)
+	for _, contact := range contacts.contacts {
+		assert.Equal(t, target, *contact.PersonID, contact.Email)
+	}
+
+	_, err = s.MergePeople(ctx, target.Hex(), target.Hex())
+	assert.ErrorIs(t, err, ErrMergeSamePerson)
+	_, err = s.MergePeople(ctx, target.Hex(), source.Hex())
+	assert.ErrorIs(t, err, repositories.ErrPersonNotFound)
+}
+
+// failingMoveContactRepository fails every contact move.
+type failingMoveContactRepository struct {
+	*memoryContactRepository
+}
+
+func (r failingMoveContactRepository) MoveContactsToPerson(context.Context, primitive.ObjectID, primitive.ObjectID) (int64, error) {
+	return 0, errors.New("move failed")
+}
+
+func TestMergePeopleKeepsSourceWhenContactsCannotMove(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+	s := NewContactService(failingMoveContactRepository{contacts}, people)
+
+	for _, email := range []string{"alex@example.com", "alex.smith@example.com"} {
+		require.NoError(t, s.CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: email}))
+	}
+	target := *contacts.contacts[0].PersonID
+	source := *contacts.contacts[1].PersonID
+
+	_, err := s.MergePeople(ctx, target.Hex(), source.Hex())
+	assert.Error(t, err)
+
+	_, err = s.GetPerson(ctx, source.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, source, *contacts.contacts[1].PersonID)
+}
+
+// failingCreateContactRepository fails every insert.
+type failingCreateContactRepository struct {
+	*memoryContactRepository
+}
+
+func (r failingCreateContactRepository) CreateContact(context.Context, *repositories.Contact) error {
+	return errors.New("insert failed")
+}
+
+func TestCreateContactCountsOnlyStoredSubmissions(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+
+	require.NoError(t, NewContactService(contacts, people).CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: "alex@example.com"}))
+	person := *contacts.contacts[0].PersonID
+
+	err := NewContactService(failingCreateContactRepository{contacts}, people).CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: "alex@example.com"})
+	assert.Error(t, err)
+	assert.Len(t, contacts.contacts, 1)
+	assert.Equal(t, 1, people.people[person].MessageCount)
+}
+
+func TestUpdateContactEmailRelinksPerson(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+	s := NewContactService(contacts, people)
+
+	for _, email := range []string{"alex@example.com", "alex@example.com", "sam@example.com"} {
+		require.NoError(t, s.CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: email}))
+	}
+	alex := *contacts.contacts[0].PersonID
+	sam := *contacts.contacts[2].PersonID
+
+	email := "Sam@Example.com"
+	updated, err := s.UpdateContact(ctx, contacts.contacts[1].ID.Hex(), repositories.ContactUpdate{Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, sam, *updated.PersonID)
+	assert.Equal(t, sam, *contacts.contacts[1].PersonID)
+	assert.Equal(t, 1, people.people[alex].MessageCount)
+	assert.Equal(t, 2, people.people[sam].MessageCount)
+
+	// Changing the case of the address keeps the same person.
+	email = "SAM@example.com"
+	updated, err = s.UpdateContact(ctx, contacts.contacts[1].ID.Hex(), repositories.ContactUpdate{Email: &email})
+	require.NoError(t, err)
+	assert.Equal(t, sam, *updated.PersonID)
+	assert.Equal(t, 2, people.people[sam].MessageCount)
+
+	// A new address gets a new person.
+	email = "alex.smith@example.com"
+	updated, err = s.UpdateContact(ctx, contacts.contacts[0].ID.Hex(), repositories.ContactUpdate{Email: &email})
+	require.NoError(t, err)
+	assert.NotEqual(t, alex, *updated.PersonID)
+	assert.Equal(t, 0, people.people[alex].MessageCount)
+	assert.Equal(t, 1, people.people[*updated.PersonID].MessageCount)
+}
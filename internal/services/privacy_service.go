@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+// Privacy request actions a data subject can ask for.
+const (
+	PrivacyActionExport = "export"
+	PrivacyActionErase  = "erase"
+)
+
+// privacyLinkExpiry is how long an emailed self-service link works.
+const privacyLinkExpiry = 24 * time.Hour
+
+var (
+	ErrInvalidPrivacyAction = errors.New("action must be export or erase")
+	ErrInvalidPrivacyToken  = errors.New("invalid or expired privacy link")
+	ErrPrivacySelfService   = errors.New("self-service privacy requests are disabled")
+)
+
+// PrivacyRepositories are the stores holding personal data about someone.
+type PrivacyRepositories struct {
+	Contacts      repositories.ContactRepository
+	People        repositories.PersonRepository
+	Notes         repositories.NoteRepository
+	Bookings      repositories.BookingRepository
+	Consents      repositories.ConsentRepository
+	Subscriptions repositories.SubscriptionRepository
+	Verification  repositories.VerificationRepository
+	AutoReplies   repositories.AutoReplyRepository
+	Sessions      repositories.SessionRepository
+}
+
+// VerificationCodeRecord describes a stored login code without the code.
+type VerificationCodeRecord struct {
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PrivacyArchive is everything Chanterelle stores about an email address.
+// Trashed contacts are included, along with their Mailchimp sync state.
+// Failed sign-ins, lockouts and sessions only exist for admin addresses.
+type PrivacyArchive struct {
+	Email                string                             `json:"email"`
+	GeneratedAt          time.Time                          `json:"generated_at"`
+	Person               *repositories.Person               `json:"person,omitempty"`
+	Contacts             []repositories.Contact             `json:"contacts"`
+	Notes                []repositories.Note                `json:"notes"`
+	Bookings             []repositories.Booking             `json:"bookings"`
+	Consents             []repositories.Consent             `json:"consents"`
+	Subscriptions        []repositories.Subscription        `json:"subscriptions"`
+	VerificationCodes    []VerificationCodeRecord           `json:"verification_codes"`
+	VerificationFailures []repositories.VerificationFailure `json:"verification_failures"`
+	VerificationLockouts []repositories.VerificationLockout `json:"verification_lockouts"`
+	Sessions             []repositories.Session             `json:"sessions"`
+	LastAutoReplyAt      *time.Time                         `json:"last_auto_reply_at,omitempty"`
+}
+
+// ErasureReport counts what an erasure removed. Consents are anonymized
+// rather than deleted so the list can still show consent was given.
+type ErasureReport struct {
+	Email                string `json:"email"`
+	ContactsDeleted      int64  `json:"contacts_deleted"`
+	NotesDeleted         int64  `json:"notes_deleted"`
+	BookingsDeleted      int64  `json:"bookings_deleted"`
+	SubscriptionsDeleted int64  `json:"subscriptions_deleted"`
+	CodesDeleted         int64  `json:"verification_codes_deleted"`
+	FailuresDeleted      int64  `json:"verification_failures_deleted"`
+	LockoutsDeleted      int64  `json:"verification_lockouts_deleted"`
+	SessionsDeleted      int64  `json:"sessions_deleted"`
+	ConsentsAnonymized   int64  `json:"consents_anonymized"`
+	MailchimpArchived    bool   `json:"mailchimp_archived"`
+	// MailchimpError is set when the local data was erased but the
+	// Mailchimp member could not be archived; erase again to retry.
+	MailchimpError string `json:"mailchimp_error,omitempty"`
+}
+
+// PrivacyService answers data subject access and erasure requests, either
+// from an admin or from the person themselves through an emailed link.
+type PrivacyService struct {
+	repos               PrivacyRepositories
+	notificationService *NotificationService
+	secret              []byte
+	selfService         bool
+	frontendURL         string
+}
+
+func NewPrivacyService(repos PrivacyRepositories, notificationService *NotificationService, cfg *config.Config) *PrivacyService {
+	return &PrivacyService{
+		repos:               repos,
+		notificationService: notificationService,
+		secret:              []byte(cfg.JWTSecret),
+		selfService:         cfg.PrivacySelfService,
+		frontendURL:         strings.TrimRight(cfg.FrontendURL, "/"),
+	}
+}
+
+// Export gathers everything stored about email.
+func (s *PrivacyService) Export(ctx context.Context, email string) (PrivacyArchive, error) {
+	email = normalizeEmail(email)
+	archive := PrivacyArchive{Email: email, GeneratedAt: time.Now()}
+
+	person, err := s.repos.People.GetPersonByEmail(ctx, email)
+	switch {
+	case err == nil:
+		archive.Person = &person
+	case !errors.Is(err, repositories.ErrPersonNotFound):
+		return PrivacyArchive{}, err
+	}
+
+	if archive.Contacts, err = s.repos.Contacts.GetContactsByEmail(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+	archive.Notes = []repositories.Note{}
+	for _, contact := range archive.Contacts {
+		notes, err := s.repos.Notes.GetNotesByContactID(ctx, contact.ID)
+		if err != nil {
+			return PrivacyArchive{}, err
+		}
+		archive.Notes = append(archive.Notes, notes...)
+	}
+
+	if archive.Bookings, err = s.repos.Bookings.GetBookingsByEmail(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+	if archive.Consents, err = s.repos.Consents.GetConsentsByEmails(ctx, []string{email}); err != nil {
+		return PrivacyArchive{}, err
+	}
+	if archive.Subscriptions, err = s.repos.Subscriptions.GetSubscriptionsByEmail(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+
+	codes, err := s.repos.Verification.GetCodesByEmail(ctx, email)
+	if err != nil {
+		return PrivacyArchive{}, err
+	}
+	archive.VerificationCodes = []VerificationCodeRecord{}
+	for _, code := range codes {
+		archive.VerificationCodes = append(archive.VerificationCodes, VerificationCodeRecord{
+			CreatedAt: code.CreatedAt,
+			ExpiresAt: code.ExpiresAt,
+		})
+	}
+
+	if archive.VerificationFailures, err = s.repos.Verification.GetFailures(ctx, repositories.VerificationFailureFilter{Email: email}); err != nil {
+		return PrivacyArchive{}, err
+	}
+	if archive.VerificationLockouts, err = s.repos.Verification.GetLockoutsByEmail(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+	if archive.Sessions, err = s.repos.Sessions.GetSessionsByEmail(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+
+	if archive.LastAutoReplyAt, err = s.repos.AutoReplies.GetLastAutoReply(ctx, email); err != nil {
+		return PrivacyArchive{}, err
+	}
+	return archive, nil
+}
+
+// Erase deletes everything stored about email, anonymizes its consent
+// records and archives it in Mailchimp. Erasing an unknown address does
+// nothing, so a failed erasure can simply be retried.
+func (s *PrivacyService) Erase(ctx context.Context, email, erasedBy string) (ErasureReport, error) {
+	email = normalizeEmail(email)
+	report := ErasureReport{Email: email}
+
+	contacts, err := s.repos.Contacts.GetContactsByEmail(ctx, email)
+	if err != nil {
+		return report, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(contacts))
+	for _, contact := range contacts {
+		ids = append(ids, contact.ID)
+	}
+	if report.NotesDeleted, err = s.repos.Notes.DeleteNotesByContactIDs(ctx, ids); err != nil {
+		return report, err
+	}
+	if report.ContactsDeleted, err = s.repos.Contacts.DeleteContactsByIDs(ctx, ids); err != nil {
+		return report, err
+	}
+	if err := s.repos.People.RemovePersonEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.BookingsDeleted, err = s.repos.Bookings.DeleteBookingsByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.SubscriptionsDeleted, err = s.repos.Subscriptions.DeleteSubscriptionsByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.CodesDeleted, err = s.repos.Verification.DeleteCodesByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.FailuresDeleted, err = s.repos.Verification.DeleteFailuresByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.LockoutsDeleted, err = s.repos.Verification.DeleteLockoutsByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if report.SessionsDeleted, err = s.repos.Sessions.DeleteSessionsByEmail(ctx, email); err != nil {
+		return report, err
+	}
+	if err := s.repos.AutoReplies.DeleteAutoReply(ctx, email); err != nil {
+		return report, err
+	}
+	if report.ConsentsAnonymized, err = s.repos.Consents.AnonymizeConsents(ctx, email, erasedEmailPseudonym(email)); err != nil {
+		return report, err
+	}
+
+	if err := s.notificationService.ArchiveMailchimpMember(email); err != nil {
+		log.Printf("Failed to archive erased email in Mailchimp: %v", err)
+		report.MailchimpError = err.Error()
+	} else {
+		report.MailchimpArchived = true
+	}
+
+	log.Printf("Erased personal data for a data subject at the request of %s: %d contacts, %d bookings",
+		erasedBy, report.ContactsDeleted, report.BookingsDeleted)
+	return report, nil
+}
+
+// RequestSelfService emails a link that lets the owner of email export or
+// erase their data. Nothing tells the requester whether any data exists.
+func (s *PrivacyService) RequestSelfService(ctx context.Context, email, action string) error {
+	if !s.selfService {
+		return ErrPrivacySelfService
+	}
+	if action != PrivacyActionExport && action != PrivacyActionErase {
+		return ErrInvalidPrivacyAction
+	}
+
+	email = normalizeEmail(email)
+	expiresAt := time.Now().Add(privacyLinkExpiry)
+	payload := email + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	token := signToken(s.secret, "privacy-"+action, payload)
+	link := s.frontendURL + "/privacy?action=" + action + "&token=" + url.QueryEscape(token)
+	return s.notificationService.SendPrivacyRequestLink(email, action, link, expiresAt)
+}
+
+// VerifySelfService checks a link sent by RequestSelfService for action and
+// returns the email it was sent to.
+func (s *PrivacyService) VerifySelfService(token, action string) (string, error) {
+	if !s.selfService {
+		return "", ErrPrivacySelfService
+	}
+	payload, ok := verifyToken(s.secret, "privacy-"+action, token)
+	if !ok {
+		return "", ErrInvalidPrivacyToken
+	}
+	email, expiry, ok := strings.Cut(payload, "|")
+	if !ok {
+		return "", ErrInvalidPrivacyToken
+	}
+	expiresUnix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiresUnix, 0)) {
+		return "", ErrInvalidPrivacyToken
+	}
+	return email, nil
+}
+
+// erasedEmailPseudonym replaces an erased email on consent records. The
+// same address always maps to the same value, so a later consent can be
+// matched to an erased one only by someone who already knows the email.
+func erasedEmailPseudonym(email string) string {
+	hash := sha256.Sum256([]byte(email))
+	return fmt.Sprintf("erased:%s", hex.EncodeToString(hash[:]))
+}
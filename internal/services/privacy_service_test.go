@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivacyServiceVerifySelfService(t *testing.T) {
+	s := NewPrivacyService(PrivacyRepositories{}, nil, &config.Config{
+		JWTSecret:          "test-secret",
+		PrivacySelfService: true,
+	})
+	expiry := func(d time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+	}
+
+	token := signToken(s.secret, "privacy-erase", "fan@example.com|"+expiry(time.Hour))
+	email, err := s.VerifySelfService(token, PrivacyActionErase)
+	require.NoError(t, err)
+	assert.Equal(t, "fan@example.com", email)
+
+	// A link for one action can't be used for the other.
+	_, err = s.VerifySelfService(token, PrivacyActionExport)
+	assert.ErrorIs(t, err, ErrInvalidPrivacyToken)
+
+	expired := signToken(s.secret, "privacy-erase", "fan@example.com|"+expiry(-time.Hour))
+	_, err = s.VerifySelfService(expired, PrivacyActionErase)
+	assert.ErrorIs(t, err, ErrInvalidPrivacyToken)
+
+	s.selfService = false
+	_, err = s.VerifySelfService(token, PrivacyActionErase)
+	assert.ErrorIs(t, err, ErrPrivacySelfService)
+}
+
+func (r *memoryContactRepository) GetContactsByEmail(_ context.Context, email string) ([]repositories.Contact, error) {
+	contacts := []repositories.Contact{}
+	for _, contact := range r.contacts {
+		if normalizeEmail(contact.Email) == email {
+			contacts = append(contacts, *contact)
+		}
+	}
+	return contacts, nil
+}
+
+func (r *memoryContactRepository) DeleteContactsByIDs(_ context.Context, ids []primitive.ObjectID) (int64, error) {
+	var kept []*repositories.Contact
+	for _, contact := range r.contacts {
+		if !slices.Contains(ids, contact.ID) {
+			kept = append(kept, contact)
+		}
+	}
+	deleted := int64(len(r.contacts) - len(kept))
+	r.contacts = kept
+	return deleted, nil
+}
+
+func (r *memoryPersonRepository) GetPersonByEmail(_ context.Context, email string) (repositories.Person, error) {
+	for _, person := range r.people {
+		if slices.Contains(person.Emails, email) {
+			return *person, nil
+		}
+	}
+	return repositories.Person{}, repositories.ErrPersonNotFound
+}
+
+func (r *memoryPersonRepository) RemovePersonEmail(_ context.Context, email string) error {
+	for id, person := range r.people {
+		if !slices.Contains(person.Emails, email) {
+			continue
+		}
+		person.Emails = slices.DeleteFunc(person.Emails, func(e string) bool { return e == email })
+		if len(person.Emails) == 0 {
+			delete(r.people, id)
+		} else if person.Email == email {
+			person.Email = person.Emails[0]
+		}
+	}
+	return nil
+}
+
+func (r *memoryNoteRepository) GetNotesByContactID(_ context.Context, contactID primitive.ObjectID) ([]repositories.Note, error) {
+	notes := []repositories.Note{}
+	for _, note := range r.notes {
+		if note.ContactID == contactID {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+func (r *memoryBookingRepository) GetBookingsByEmail(_ context.Context, email string) ([]repositories.Booking, error) {
+	bookings := []repositories.Booking{}
+	for _, booking := range r.bookings {
+		if normalizeEmail(booking.Email) == email {
+			bookings = append(bookings, booking)
+		}
+	}
+	return bookings, nil
+}
+
+func (r *memoryBookingRepository) DeleteBookingsByEmail(_ context.Context, email string) (int64, error) {
+	before := len(r.bookings)
+	r.bookings = slices.DeleteFunc(r.bookings, func(b repositories.Booking) bool { return normalizeEmail(b.Email) == email })
+	return int64(before - len(r.bookings)), nil
+}
+
+func (r *memoryConsentRepository) AnonymizeConsents(_ context.Context, email, pseudonym string) (int64, error) {
+	var anonymized int64
+	for i := range r.consents {
+		if r.consents[i].Email == email {
+			r.consents[i].Email = pseudonym
+			if strings.Contains(strings.ToLower(r.consents[i].Text), email) {
+				r.consents[i].Text = repositories.RedactedConsentText
+			}
+			r.consents[i].IP = ""
+			r.consents[i].UserAgent = ""
+			anonymized++
+		}
+	}
+	return anonymized, nil
+}
+
+func (r *memorySubscriptionRepository) GetSubscriptionsByEmail(_ context.Context, email string) ([]repositories.Subscription, error) {
+	subscriptions := []repositories.Subscription{}
+	for _, subscription := range r.subscriptions {
+		if subscription.Email == email {
+			subscriptions = append(subscriptions, *subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *memorySubscriptionRepository) DeleteSubscriptionsByEmail(_ context.Context, email string) (int64, error) {
+	var deleted int64
+	for id, subscription := range r.subscriptions {
+		if subscription.Email == email {
+			delete(r.subscriptions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memoryVerificationRepository) GetCodesByEmail(_ context.Context, email string) ([]repositories.VerificationCode, error) {
+	codes := []repositories.VerificationCode{}
+	for _, code := range r.codes {
+		if normalizeEmail(code.Email) == email {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (r *memoryVerificationRepository) DeleteCodesByEmail(_ context.Context, email string) (int64, error) {
+	before := len(r.codes)
+	r.codes = slices.DeleteFunc(r.codes, func(c repositories.VerificationCode) bool { return normalizeEmail(c.Email) == email })
+	return int64(before - len(r.codes)), nil
+}
+
+func (r *memoryVerificationRepository) GetFailures(_ context.Context, filter repositories.VerificationFailureFilter) ([]repositories.VerificationFailure, error) {
+	failures := []repositories.VerificationFailure{}
+	for _, failure := range r.failures {
+		if filter.Email == "" || failure.Email == filter.Email {
+			failures = append(failures, failure)
+		}
+	}
+	return failures, nil
+}
+
+func (r *memoryVerificationRepository) DeleteFailuresByEmail(_ context.Context, email string) (int64, error) {
+	before := len(r.failures)
+	r.failures = slices.DeleteFunc(r.failures, func(f repositories.VerificationFailure) bool { return f.Email == email })
+	return int64(before - len(r.failures)), nil
+}
+
+func (r *memoryVerificationRepository) GetLockoutsByEmail(_ context.Context, email string) ([]repositories.VerificationLockout, error) {
+	lockouts := []repositories.VerificationLockout{}
+	for _, lockout := range r.lockouts {
+		if normalizeEmail(lockout.Email) == email {
+			lockouts = append(lockouts, *lockout)
+		}
+	}
+	return lockouts, nil
+}
+
+func (r *memoryVerificationRepository) DeleteLockoutsByEmail(_ context.Context, email string) (int64, error) {
+	var deleted int64
+	for key, lockout := range r.lockouts {
+		if normalizeEmail(lockout.Email) == email {
+			delete(r.lockouts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memorySessionRepository) GetSessionsByEmail(_ context.Context, email string) ([]repositories.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []repositories.Session{}
+	for _, session := range r.sessions {
+		if session.Email == email {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) DeleteSessionsByEmail(_ context.Context, email string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.Email == email {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memoryAutoReplyRepository) GetLastAutoReply(_ context.Context, email string) (*time.Time, error) {
+	last, ok := r.lastSent[email]
+	if !ok {
+		return nil, nil
+	}
+	return &last, nil
+}
+
+func (r *memoryAutoReplyRepository) DeleteAutoReply(_ context.Context, email string) error {
+	delete(r.lastSent, email)
+	return nil
+}
+
+// privacyFixture holds data about fan@example.com, which privacy requests
+// act on, and about sam@example.com, which they must leave alone.
+type privacyFixture struct {
+	repos         PrivacyRepositories
+	contacts      *memoryContactRepository
+	people        *memoryPersonRepository
+	notes         *memoryNoteRepository
+	bookings      *memoryBookingRepository
+	consents      *memoryConsentRepository
+	subscriptions *memorySubscriptionRepository
+	verification  *memoryVerificationRepository
+	autoReplies   *memoryAutoReplyRepository
+	sessions      *memorySessionRepository
+}
+
+func newPrivacyFixture(t *testing.T) *privacyFixture {
+	ctx := context.Background()
+	f := &privacyFixture{
+		contacts:      &memoryContactRepository{},
+		people:        newMemoryPersonRepository(),
+		notes:         &memoryNoteRepository{},
+		bookings:      &memoryBookingRepository{},
+		consents:      &memoryConsentRepository{},
+		subscriptions: &memorySubscriptionRepository{subscriptions: map[primitive.ObjectID]*repositories.Subscription{}},
+		verification:  newMemoryVerificationRepository(),
+		autoReplies:   &memoryAutoReplyRepository{lastSent: map[string]time.Time{}},
+		sessions:      newMemorySessionRepository(),
+	}
+	f.repos = PrivacyRepositories{
+		Contacts:      f.contacts,
+		People:        f.people,
+		Notes:         f.notes,
+		Bookings:      f.bookings,
+		Consents:      f.consents,
+		Subscriptions: f.subscriptions,
+		Verification:  f.verification,
+		AutoReplies:   f.autoReplies,
+		Sessions:      f.sessions,
+	}
+
+	trashedAt := time.Now()
+	for _, email := range []string{"Fan@Example.com", "sam@example.com"} {
+		for _, deletedAt := range []*time.Time{nil, &trashedAt} {
+			contact := &repositories.Contact{Name: "Pat Fan", Email: email, Message: "Hello"}
+			require.NoError(t, f.contacts.CreateContact(ctx, contact))
+			f.contacts.contacts[len(f.contacts.contacts)-1].DeletedAt = deletedAt
+			f.notes.notes = append(f.notes.notes, repositories.Note{ID: primitive.NewObjectID(), ContactID: contact.ID, Body: "Called back"})
+			_, err := f.people.RecordSubmission(ctx, normalizeEmail(email), "Pat Fan", time.Now())
+			require.NoError(t, err)
+		}
+
+		normalized := normalizeEmail(email)
+		f.bookings.bookings = append(f.bookings.bookings, repositories.Booking{ID: primitive.NewObjectID(), Name: "Pat Fan", Email: email})
+		f.consents.consents = append(f.consents.consents, repositories.Consent{
+			Email: normalized, Event: repositories.ConsentSignup, Text: "Yes please", IP: "203.0.113.7", UserAgent: "Firefox",
+		}, repositories.Consent{
+			// Confirmations used to quote the address as it was typed.
+			Email: normalized, Event: repositories.ConsentConfirmation, Text: "Confirmed by following the link emailed to " + email,
+		})
+		id := primitive.NewObjectID()
+		f.subscriptions.subscriptions[id] = &repositories.Subscription{ID: id, Email: normalized}
+		f.verification.codes = append(f.verification.codes, repositories.VerificationCode{CodeHash: "hash", Email: email})
+		f.verification.failures = append(f.verification.failures, repositories.VerificationFailure{Email: normalized, IP: "203.0.113.7", Reason: "wrong code"})
+		f.verification.lockouts[normalized] = &repositories.VerificationLockout{Email: normalized, Level: 1, LockedUntil: time.Now().Add(time.Minute)}
+		f.autoReplies.lastSent[normalized] = time.Now()
+		require.NoError(t, f.sessions.CreateSession(ctx, &repositories.Session{Email: normalized, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+	return f
+}
+
+func TestPrivacyFixtureCoversEveryRepository(t *testing.T) {
+	// A store added to PrivacyRepositories without a fake here would be
+	// left out of the export and erasure tests.
+	repos := reflect.ValueOf(newPrivacyFixture(t).repos)
+	for i := 0; i < repos.NumField(); i++ {
+		assert.False(t, repos.Field(i).IsNil(), repos.Type().Field(i).Name)
+	}
+}
+
+func TestPrivacyExport(t *testing.T) {
+	f := newPrivacyFixture(t)
+	s := NewPrivacyService(f.repos, nil, &config.Config{})
+
+	archive, err := s.Export(context.Background(), " FAN@example.com ")
+	require.NoError(t, err)
+
+	assert.Equal(t, "fan@example.com", archive.Email)
+	require.NotNil(t, archive.Person)
+	assert.Equal(t, []string{"fan@example.com"}, archive.Person.Emails)
+	assert.Len(t, archive.Contacts, 2, "trashed contacts are included")
+	assert.Len(t, archive.Notes, 2)
+	assert.Len(t, archive.Bookings, 1)
+	assert.Len(t, archive.Consents, 2)
+	assert.Len(t, archive.Subscriptions, 1)
+	assert.Len(t, archive.VerificationCodes, 1)
+	assert.Len(t, archive.VerificationFailures, 1)
+	assert.Len(t, archive.VerificationLockouts, 1)
+	assert.Len(t, archive.Sessions, 1)
+	assert.NotNil(t, archive.LastAutoReplyAt)
+}
+
+func TestPrivacyErase(t *testing.T) {
+	ctx := context.Background()
+	f := newPrivacyFixture(t)
+	notifications := NewNotificationService(&config.Config{})
+	mailchimp := newTestMailchimp(t, notifications)
+	s := NewPrivacyService(f.repos, notifications, &config.Config{})
+
+	report, err := s.Erase(ctx, "fan@example.com", "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, ErasureReport{
+		Email:                "fan@example.com",
+		ContactsDeleted:      2,
+		NotesDeleted:         2,
+		BookingsDeleted:      1,
+		SubscriptionsDeleted: 1,
+		CodesDeleted:         1,
+		FailuresDeleted:      1,
+		LockoutsDeleted:      1,
+		SessionsDeleted:      1,
+		ConsentsAnonymized:   2,
+		MailchimpArchived:    true,
+	}, report)
+	assert.Equal(t, 1, mailchimp.deleted())
+
+	// Nothing identifying is left, and the consent record survives under a
+	// pseudonym.
+	archive, err := s.Export(ctx, "fan@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, archive.Person)
+	assert.Empty(t, archive.Contacts)
+	assert.Empty(t, archive.Notes)
+	assert.Empty(t, archive.Bookings)
+	assert.Empty(t, archive.Consents)
+	assert.Empty(t, archive.Subscriptions)
+	assert.Empty(t, archive.VerificationCodes)
+	assert.Empty(t, archive.VerificationFailures)
+	assert.Empty(t, archive.VerificationLockouts)
+	assert.Empty(t, archive.Sessions)
+	assert.Nil(t, archive.LastAutoReplyAt)
+
+	pseudonym := erasedEmailPseudonym("fan@example.com")
+	consent := f.consents.consents[0]
+	assert.Equal(t, pseudonym, consent.Email)
+	assert.NotContains(t, pseudonym, "fan")
+	assert.Equal(t, "Yes please", consent.Text)
+	assert.Empty(t, consent.IP)
+	assert.Empty(t, consent.UserAgent)
+	confirmation := f.consents.consents[1]
+	assert.Equal(t, pseudonym, confirmation.Email)
+	assert.Equal(t, repositories.ConsentConfirmation, confirmation.Event)
+	assert.NotContains(t, strings.ToLower(confirmation.Text), "fan@example.com")
+
+	// Someone else's data is untouched.
+	other, err := s.Export(ctx, "sam@example.com")
+	require.NoError(t, err)
+	assert.NotNil(t, other.Person)
+	assert.Len(t, other.Contacts, 2)
+	assert.Len(t, other.Notes, 2)
+	assert.Len(t, other.Bookings, 1)
+	assert.Len(t, other.Consents, 2)
+	assert.Contains(t, other.Consents[1].Text, "sam@example.com")
+	assert.Len(t, other.Subscriptions, 1)
+	assert.Len(t, other.VerificationCodes, 1)
+	assert.Len(t, other.VerificationFailures, 1)
+	assert.Len(t, other.VerificationLockouts, 1)
+	assert.Len(t, other.Sessions, 1)
+	assert.NotNil(t, other.LastAutoReplyAt)
+}
+
+func TestPrivacyEraseReportsMailchimpFailure(t *testing.T) {
+	ctx := context.Background()
+	f := newPrivacyFixture(t)
+	notifications := NewNotificationService(&config.Config{})
+	mailchimp := newTestMailchimp(t, notifications)
+	mailchimp.setFailing(true)
+	s := NewPrivacyService(f.repos, notifications, &config.Config{})
+
+	report, err := s.Erase(ctx, "fan@example.com", "admin@example.com")
+	require.NoError(t, err)
+	assert.False(t, report.MailchimpArchived)
+	assert.Contains(t, report.MailchimpError, "503")
+	assert.Equal(t, int64(2), report.ContactsDeleted)
+
+	archive, err := s.Export(ctx, "fan@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, archive.Contacts)
+	assert.Empty(t, archive.Bookings)
+
+	// Erasing again retries Mailchimp.
+	mailchimp.setFailing(false)
+	report, err = s.Erase(ctx, "fan@example.com", "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, report.MailchimpArchived)
+	assert.Empty(t, report.MailchimpError)
+	assert.Zero(t, report.ContactsDeleted)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+var (
+	ErrEmptyReply   = errors.New("reply message is required")
+	ErrReplyNotSent = errors.New("failed to send reply")
+)
+
+const defaultReplySubject = "Re: your message to Chanterelle"
+
+type ReplyService struct {
+	contactService      *ContactService
+	notificationService *NotificationService
+}
+
+func NewReplyService(contactService *ContactService, notificationService *NotificationService) *ReplyService {
+	return &ReplyService{
+		contactService:      contactService,
+		notificationService: notificationService,
+	}
+}
+
+// Reply emails an admin's reply to a contact, records it on the contact's
+// thread and marks the contact as replied when its status allows. Nothing
+// is recorded if the email can't be sent.
+func (s *ReplyService) Reply(ctx context.Context, id, subject, body, sentBy string) (repositories.Contact, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return repositories.Contact{}, ErrEmptyReply
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		subject = defaultReplySubject
+	}
+
+	contact, err := s.contactService.GetContactByID(ctx, id)
+	if err != nil {
+		return repositories.Contact{}, err
+	}
+
+	if err := s.notificationService.SendContactReply(&models.Contact{
+		Name:  contact.Name,
+		Email: contact.Email,
+	}, subject, body); err != nil {
+		return repositories.Contact{}, fmt.Errorf("%w: %v", ErrReplyNotSent, err)
+	}
+
+	now := time.Now()
+	reply := repositories.ContactReply{
+		Subject: subject,
+		Body:    body,
+		SentBy:  sentBy,
+		SentAt:  now,
+	}
+
+	var change *repositories.StatusChange
+	if CanTransition(contact.Status, repositories.ContactStatusReplied) {
+		change = &repositories.StatusChange{
+			From:      contact.Status,
+			To:        repositories.ContactStatusReplied,
+			ChangedBy: sentBy,
+			ChangedAt: now,
+		}
+	}
+
+	updated, err := s.contactService.AddContactReply(ctx, id, reply, change)
+	if errors.Is(err, repositories.ErrStatusChanged) {
+		// Someone changed the status while the email was being sent; keep
+		// their status but still record the reply.
+		updated, err = s.contactService.AddContactReply(ctx, id, reply, nil)
+	}
+	return updated, err
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentEmails records the emails sent through a test NotificationService.
+type sentEmails struct {
+	mu       sync.Mutex
+	requests []emailJSRequest
+}
+
+func (s *sentEmails) all() []emailJSRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]emailJSRequest(nil), s.requests...)
+}
+
+// newTestNotificationService returns a NotificationService whose EmailJS
+// emails go to a local server that records them.
+func newTestNotificationService(t *testing.T, cfg *config.Config) (*NotificationService, *sentEmails) {
+	sent := &sentEmails{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req emailJSRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		sent.mu.Lock()
+		sent.requests = append(sent.requests, req)
+		sent.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.EmailJSServiceID = "test-service-id"
+	cfg.EmailJSTemplateID = "test-template-id"
+	cfg.EmailJSUserID = "test-user-id"
+	cfg.EmailJSAccessToken = "test-access-token"
+	s := NewNotificationService(cfg)
+	s.emailJSURL = server.URL
+	return s, sent
+}
+
+// replyContactRepository holds one contact whose status another admin
+// changes to statusChangedTo just before the first reply is recorded.
+type replyContactRepository struct {
+	repositories.ContactRepository
+	contact         repositories.Contact
+	statusChangedTo repositories.ContactStatus
+	changes         []*repositories.StatusChange
+}
+
+func (r *replyContactRepository) GetContactByID(_ context.Context, id string) (repositories.Contact, error) {
+	if id != r.contact.ID.Hex() {
+		return repositories.Contact{}, repositories.ErrContactNotFound
+	}
+	return r.contact, nil
+}
+
+func (r *replyContactRepository) AddContactReply(_ context.Context, _ string, reply repositories.ContactReply, change *repositories.StatusChange) (repositories.Contact, error) {
+	r.changes = append(r.changes, change)
+	if r.statusChangedTo != "" {
+		r.contact.Status = r.statusChangedTo
+		r.statusChangedTo = ""
+	}
+	if change != nil {
+		if r.contact.Status != change.From {
+			return repositories.Contact{}, repositories.ErrStatusChanged
+		}
+		r.contact.Status = change.To
+	}
+	r.contact.Replies = append(r.contact.Replies, reply)
+	return r.contact, nil
+}
+
+func TestReplyMarksContactReplied(t *testing.T) {
+	notifications, sent := newTestNotificationService(t, &config.Config{})
+	contacts := &replyContactRepository{contact: repositories.Contact{
+		ID:     primitive.NewObjectID(),
+		Name:   "Alex",
+		Email:  "alex@example.com",
+		Status: repositories.ContactStatusRead,
+	}}
+	s := NewReplyService(NewContactService(contacts, nil), notifications)
+
+	contact, err := s.Reply(context.Background(), contacts.contact.ID.Hex(), "", " Thanks! ", "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, repositories.ContactStatusReplied, contact.Status)
+	require.Len(t, contact.Replies, 1)
+	assert.Equal(t, "Thanks!", contact.Replies[0].Body)
+	assert.Equal(t, defaultReplySubject, contact.Replies[0].Subject)
+	assert.Len(t, sent.all(), 1)
+}
+
+func TestReplyKeepsStatusChangedWhileSending(t *testing.T) {
+	notifications, sent := newTestNotificationService(t, &config.Config{})
+	contacts := &replyContactRepository{
+		contact: repositories.Contact{
+			ID:     primitive.NewObjectID(),
+			Name:   "Alex",
+			Email:  "alex@example.com",
+			Status: repositories.ContactStatusNew,
+		},
+		statusChangedTo: repositories.ContactStatusArchived,
+	}
+	s := NewReplyService(NewContactService(contacts, nil), notifications)
+
+	contact, err := s.Reply(context.Background(), contacts.contact.ID.Hex(), "Re: booking", "Thanks!", "admin@example.com")
+	require.NoError(t, err)
+
+	// The reply is recorded on the second try, without a status change.
+	require.Len(t, contacts.changes, 2)
+	require.NotNil(t, contacts.changes[0])
+	assert.Equal(t, repositories.ContactStatusReplied, contacts.changes[0].To)
+	assert.Nil(t, contacts.changes[1])
+	assert.Equal(t, repositories.ContactStatusArchived, contact.Status)
+	require.Len(t, contact.Replies, 1)
+	assert.Equal(t, "Re: booking", contact.Replies[0].Subject)
+	assert.Len(t, sent.all(), 1, "the email is sent once")
+}
+
+func TestReplyRecordsNothingWhenEmailFails(t *testing.T) {
+	contacts := &replyContactRepository{contact: repositories.Contact{
+		ID:     primitive.NewObjectID(),
+		Email:  "alex@example.com",
+		Status: repositories.ContactStatusNew,
+	}}
+	// No EmailJS configuration, so sending fails.
+	s := NewReplyService(NewContactService(contacts, nil), NewNotificationService(&config.Config{}))
+
+	_, err := s.Reply(context.Background(), contacts.contact.ID.Hex(), "", "Thanks!", "admin@example.com")
+	assert.ErrorIs(t, err, ErrReplyNotSent)
+	assert.Empty(t, contacts.changes)
+
+	_, err = s.Reply(context.Background(), contacts.contact.ID.Hex(), "", "  ", "admin@example.com")
+	assert.ErrorIs(t, err, ErrEmptyReply)
+}
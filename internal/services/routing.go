@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+var ErrUnknownContactCategory = errors.New("unknown contact category")
+
+// RoutingService applies the admin-configured routing rule for a contact's
+// category once the contact has been stored.
+type RoutingService struct {
+	rules               repositories.RoutingRuleRepository
+	notificationService *NotificationService
+	subscriptions       *SubscriptionService
+	adminEmail          string
+}
+
+func NewRoutingService(rules repositories.RoutingRuleRepository, notificationService *NotificationService, subscriptions *SubscriptionService, cfg *config.Config) *RoutingService {
+	return &RoutingService{
+		rules:               rules,
+		notificationService: notificationService,
+		subscriptions:       subscriptions,
+		adminEmail:          cfg.AdminEmail,
+	}
+}
+
+// DefaultRoutingRule is the rule for a category nobody has configured yet.
+// Fans writing in are subscribed; business inquiries are not, and the admin
+// is told about them instead.
+func (s *RoutingService) DefaultRoutingRule(category repositories.ContactCategory) repositories.RoutingRule {
+	rule := repositories.RoutingRule{Category: category, NotifyEmails: []string{}}
+	switch category {
+	case repositories.ContactCategoryGeneral, repositories.ContactCategoryFanMail:
+		rule.Subscribe = true
+	default:
+		if s.adminEmail != "" {
+			rule.NotifyEmails = []string{s.adminEmail}
+		}
+	}
+	return rule
+}
+
+// GetRoutingRules returns the rule in effect for every category.
+func (s *RoutingService) GetRoutingRules(ctx context.Context) ([]repositories.RoutingRule, error) {
+	saved, err := s.rules.GetRoutingRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := make(map[repositories.ContactCategory]repositories.RoutingRule, len(saved))
+	for _, rule := range saved {
+		byCategory[rule.Category] = rule
+	}
+
+	rules := make([]repositories.RoutingRule, 0, len(repositories.ContactCategories))
+	for _, category := range repositories.ContactCategories {
+		rule, ok := byCategory[category]
+		if !ok {
+			rule = s.DefaultRoutingRule(category)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// GetRoutingRule returns the rule in effect for a category.
+func (s *RoutingService) GetRoutingRule(ctx context.Context, category repositories.ContactCategory) (repositories.RoutingRule, error) {
+	if !category.IsValid() {
+		return repositories.RoutingRule{}, fmt.Errorf("%w: %s", ErrUnknownContactCategory, category)
+	}
+	rule, err := s.rules.GetRoutingRule(ctx, category)
+	if errors.Is(err, repositories.ErrRoutingRuleNotFound) {
+		return s.DefaultRoutingRule(category), nil
+	}
+	return rule, err
+}
+
+func (s *RoutingService) SaveRoutingRule(ctx context.Context, rule repositories.RoutingRule, updatedBy string) (repositories.RoutingRule, error) {
+	if !rule.Category.IsValid() {
+		return repositories.RoutingRule{}, fmt.Errorf("%w: %s", ErrUnknownContactCategory, rule.Category)
+	}
+
+	emails := make([]string, 0, len(rule.NotifyEmails))
+	seen := map[string]bool{}
+	for _, email := range rule.NotifyEmails {
+		email = normalizeEmail(email)
+		if email != "" && !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	rule.NotifyEmails = emails
+	rule.AutoReplyTemplateID = strings.TrimSpace(rule.AutoReplyTemplateID)
+
+	now := time.Now()
+	rule.UpdatedAt = &now
+	rule.UpdatedBy = updatedBy
+	if err := s.rules.SaveRoutingRule(ctx, rule); err != nil {
+		return repositories.RoutingRule{}, err
+	}
+	return rule, nil
+}
+
+// RouteContact notifies the rule's recipients about a new contact and, if
+// the rule allows signups and the sender gave consent, signs them up to the
+// mailing list. consent is nil when the sender did not ask to join. The
+// contact is already stored, so failures are logged rather than returned.
+func (s *RoutingService) RouteContact(ctx context.Context, contact *repositories.Contact, consent *SignupConsent) {
+	rule, err := s.GetRoutingRule(ctx, contact.Category)
+	if err != nil {
+		log.Printf("Failed to load routing rule for %s, using the default: %v", contact.Category, err)
+		rule = s.DefaultRoutingRule(contact.Category)
+	}
+
+	model := &models.Contact{
+		Name:    contact.Name,
+		Email:   contact.Email,
+		Message: contact.Message,
+	}
+
+	for _, recipient := range rule.NotifyEmails {
+		if err := s.notificationService.SendRoutedContactNotification(model, string(contact.Category), recipient); err != nil {
+			log.Printf("Failed to notify %s about contact %s: %v", recipient, contact.ID.Hex(), err)
+		}
+	}
+
+	if rule.Subscribe && consent != nil {
+		if err := s.subscriptions.Subscribe(ctx, contact, *consent); err != nil {
+			log.Printf("Failed to subscribe contact %s: %v", contact.ID.Hex(), err)
+		}
+	}
+}
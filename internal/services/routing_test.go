@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRoutingRuleRepository is an in-memory RoutingRuleRepository for
+// tests. err, when set, is returned by every read.
+type memoryRoutingRuleRepository struct {
+	rules map[repositories.ContactCategory]repositories.RoutingRule
+	err   error
+}
+
+func newMemoryRoutingRuleRepository() *memoryRoutingRuleRepository {
+	return &memoryRoutingRuleRepository{rules: map[repositories.ContactCategory]repositories.RoutingRule{}}
+}
+
+func (r *memoryRoutingRuleRepository) GetRoutingRules(_ context.Context) ([]repositories.RoutingRule, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	rules := []repositories.RoutingRule{}
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r *memoryRoutingRuleRepository) GetRoutingRule(_ context.Context, category repositories.ContactCategory) (repositories.RoutingRule, error) {
+	if r.err != nil {
+		return repositories.RoutingRule{}, r.err
+	}
+	rule, ok := r.rules[category]
+	if !ok {
+		return repositories.RoutingRule{}, repositories.ErrRoutingRuleNotFound
+	}
+	return rule, nil
+}
+
+func (r *memoryRoutingRuleRepository) SaveRoutingRule(_ context.Context, rule repositories.RoutingRule) error {
+	r.rules[rule.Category] = rule
+	return nil
+}
+
+func TestDefaultRoutingRules(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		category   repositories.ContactCategory
+		adminEmail string
+		subscribe  bool
+		notify     []string
+	}{
+		{category: repositories.ContactCategoryGeneral, adminEmail: "admin@example.com", subscribe: true, notify: []string{}},
+		{category: repositories.ContactCategoryFanMail, adminEmail: "admin@example.com", subscribe: true, notify: []string{}},
+		{category: repositories.ContactCategoryBooking, adminEmail: "admin@example.com", notify: []string{"admin@example.com"}},
+		{category: repositories.ContactCategoryPress, adminEmail: "admin@example.com", notify: []string{"admin@example.com"}},
+		{category: repositories.ContactCategoryLicensing, adminEmail: "admin@example.com", notify: []string{"admin@example.com"}},
+		{category: repositories.ContactCategoryPress, notify: []string{}},
+	}
+	for _, tt := range tests {
+		s := NewRoutingService(newMemoryRoutingRuleRepository(), nil, nil, &config.Config{AdminEmail: tt.adminEmail})
+
+		rule, err := s.GetRoutingRule(ctx, tt.category)
+		require.NoError(t, err)
+		assert.Equal(t, tt.category, rule.Category)
+		assert.Equal(t, tt.subscribe, rule.Subscribe, tt.category)
+		assert.Equal(t, tt.notify, rule.NotifyEmails, tt.category)
+		assert.Empty(t, rule.AutoReplyTemplateID)
+	}
+}
+
+func TestRoutingRulesSavedOverDefaults(t *testing.T) {
+	ctx := context.Background()
+	s := NewRoutingService(newMemoryRoutingRuleRepository(), nil, nil, &config.Config{AdminEmail: "admin@example.com"})
+
+	saved, err := s.SaveRoutingRule(ctx, repositories.RoutingRule{
+		Category:            repositories.ContactCategoryPress,
+		NotifyEmails:        []string{" Press@Example.com", "press@example.com", "", "pr@example.com"},
+		AutoReplyTemplateID: " template_press ",
+	}, "admin@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"press@example.com", "pr@example.com"}, saved.NotifyEmails)
+	assert.Equal(t, "template_press", saved.AutoReplyTemplateID)
+	assert.Equal(t, "admin@example.com", saved.UpdatedBy)
+	assert.NotNil(t, saved.UpdatedAt)
+
+	rule, err := s.GetRoutingRule(ctx, repositories.ContactCategoryPress)
+	require.NoError(t, err)
+	assert.Equal(t, saved, rule)
+
+	rules, err := s.GetRoutingRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, len(repositories.ContactCategories))
+	for i, rule := range rules {
+		assert.Equal(t, repositories.ContactCategories[i], rule.Category)
+		if rule.Category != repositories.ContactCategoryPress {
+			assert.Nil(t, rule.UpdatedAt, "unsaved categories use the default")
+		}
+	}
+
+	_, err = s.GetRoutingRule(ctx, "gossip")
+	assert.ErrorIs(t, err, ErrUnknownContactCategory)
+	_, err = s.SaveRoutingRule(ctx, repositories.RoutingRule{Category: "gossip"}, "admin@example.com")
+	assert.ErrorIs(t, err, ErrUnknownContactCategory)
+}
+
+func TestRouteContactNotifiesEveryRecipient(t *testing.T) {
+	ctx := context.Background()
+	rules := newMemoryRoutingRuleRepository()
+	rules.rules[repositories.ContactCategoryPress] = repositories.RoutingRule{
+		Category:     repositories.ContactCategoryPress,
+		NotifyEmails: []string{"press@example.com", "manager@example.com"},
+	}
+	notifications, sent := newTestNotificationService(t, &config.Config{})
+	s := NewRoutingService(rules, notifications, nil, &config.Config{AdminEmail: "admin@example.com"})
+
+	contact := &repositories.Contact{
+		ID:       primitive.NewObjectID(),
+		Name:     "Jo Writer",
+		Email:    "jo@paper.example",
+		Message:  "Interview request",
+		Category: repositories.ContactCategoryPress,
+	}
+	s.RouteContact(ctx, contact, nil)
+
+	emails := sent.all()
+	require.Len(t, emails, 2)
+	assert.Equal(t, "press@example.com", emails[0].TemplateParams.Email)
+	assert.Equal(t, "manager@example.com", emails[1].TemplateParams.Email)
+	assert.Equal(t, "New press message from Jo Writer", emails[0].TemplateParams.Destination)
+	assert.Contains(t, emails[0].TemplateParams.Message, "jo@paper.example")
+
+	// When the rules can't be read the default rule still tells the admin.
+	rules.err = errors.New("database unavailable")
+	contact.Category = repositories.ContactCategoryLicensing
+	s.RouteContact(ctx, contact, nil)
+
+	emails = sent.all()
+	require.Len(t, emails, 3)
+	assert.Equal(t, "admin@example.com", emails[2].TemplateParams.Email)
+}
+
+func TestRouteContactSubscribesOnlyWithConsent(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{ConsentText: "Sign me up."}
+	contact := &repositories.Contact{ID: primitive.NewObjectID(), Name: "Pat Fan", Email: "fan@example.com", Category: repositories.ContactCategoryFanMail}
+	consents := &memoryConsentRepository{}
+	consentService := NewConsentService(consents, nil, cfg)
+	mailchimpSync := NewMailchimpSyncService(&memoryContactRepository{contacts: []*repositories.Contact{contact}}, nil, cfg)
+	subscriptions := NewSubscriptionService(nil, mailchimpSync, nil, consentService, cfg)
+	s := NewRoutingService(newMemoryRoutingRuleRepository(), nil, subscriptions, cfg)
+
+	s.RouteContact(ctx, contact, nil)
+	assert.Empty(t, consents.consents)
+	assert.Nil(t, contact.MailchimpSync)
+
+	_, err := consentService.SignupConsent("stale", ConsentEvidence{})
+	assert.ErrorIs(t, err, ErrConsentTextChanged)
+
+	consent, err := consentService.SignupConsent(consentService.ConsentVersion(), ConsentEvidence{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	s.RouteContact(ctx, contact, &consent)
+	require.Len(t, consents.consents, 1)
+	assert.Equal(t, repositories.ConsentSignup, consents.consents[0].Event)
+	assert.Equal(t, "Sign me up.", consents.consents[0].Text)
+	assert.Equal(t, "192.0.2.1", consents.consents[0].IP)
+	require.NotNil(t, contact.MailchimpSync)
+	assert.Equal(t, repositories.MailchimpSyncPending, contact.MailchimpSync.Status)
+}
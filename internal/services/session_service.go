@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	// refreshGracePeriod is how long a refresh token can be used again
+	// after it was exchanged, so tabs refreshing at the same time all
+	// succeed.
+	refreshGracePeriod = 30 * time.Second
+	// maxSpareRefreshTokens and maxUsedRefreshTokens cap the refresh token
+	// hashes kept on a session.
+	maxSpareRefreshTokens = 5
+	maxUsedRefreshTokens  = 20
+	// refreshRetries is how often a refresh that raced another one is
+	// tried again.
+	refreshRetries = 3
+)
+
+var (
+	ErrInvalidAccessToken  = errors.New("invalid token")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// errRefreshTokenReused means an exchanged refresh token was presented
+	// again after the grace period, so it has probably been stolen.
+	errRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// SessionTokens are issued when an admin signs in and on every refresh.
+// The access token is a short-lived JWT; the refresh token is opaque and
+// can be used once.
+type SessionTokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int `json:"expires_in"`
+}
+
+// AccessClaims identify the admin and session behind an access token.
+type AccessClaims struct {
+	Email     string
+	Role      repositories.AdminRole
+	SessionID primitive.ObjectID
+}
+
+// SessionService signs admins in and out. Every sign-in starts a session
+// that lasts as long as its refresh token keeps being used; access tokens
+// are only accepted while their session is active.
+type SessionService struct {
+	repository repositories.SessionRepository
+	admins     repositories.AdminRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewSessionService(repository repositories.SessionRepository, admins repositories.AdminRepository, cfg *config.Config) *SessionService {
+	return &SessionService{
+		repository: repository,
+		admins:     admins,
+		secret:     []byte(cfg.JWTSecret),
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}
+}
+
+// StartSession signs an admin in on a new device.
+func (s *SessionService) StartSession(ctx context.Context, admin repositories.Admin, ip, userAgent string) (SessionTokens, error) {
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	now := time.Now()
+	session := repositories.Session{
+		Email:       admin.Email,
+		RefreshHash: hashRefreshSecret(secret),
+		UserAgent:   userAgent,
+		IP:          ip,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	}
+	if err := s.repository.CreateSession(ctx, &session); err != nil {
+		return SessionTokens{}, err
+	}
+	return s.issueTokens(admin, session.ID, secret)
+}
+
+// Refresh exchanges a refresh token for new tokens and keeps the session
+// alive for another refresh TTL. A token that was already exchanged works
+// again only briefly; after that, presenting it ends the session. The role
+// is read again, so removed admins can't refresh and role changes apply.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken, ip, userAgent string) (SessionTokens, error) {
+	id, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return SessionTokens{}, ErrInvalidRefreshToken
+	}
+
+	newSecret, err := newRefreshSecret()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	session, err := s.rotateRefreshToken(ctx, id, hashRefreshSecret(secret), hashRefreshSecret(newSecret), ip, userAgent)
+	switch {
+	case errors.Is(err, errRefreshTokenReused):
+		if err := s.repository.RevokeSession(ctx, session.ID, session.Email, "refresh token reused"); err != nil && !errors.Is(err, repositories.ErrSessionNotFound) {
+			return SessionTokens{}, err
+		}
+		log.Printf("Revoked session %s after its refresh token was reused from %s", id.Hex(), ip)
+		return SessionTokens{}, ErrInvalidRefreshToken
+	case errors.Is(err, repositories.ErrSessionNotFound):
+		return SessionTokens{}, ErrInvalidRefreshToken
+	case err != nil:
+		return SessionTokens{}, err
+	}
+
+	admin, err := s.admins.GetAdmin(ctx, session.Email)
+	if errors.Is(err, repositories.ErrAdminNotFound) {
+		if err := s.repository.RevokeSession(ctx, session.ID, session.Email, "admin removed"); err != nil {
+			return SessionTokens{}, err
+		}
+		return SessionTokens{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return SessionTokens{}, err
+	}
+	return s.issueTokens(admin, session.ID, newSecret)
+}
+
+// rotateRefreshToken replaces the refresh token hashed as oldHash with
+// newHash. It returns ErrInvalidRefreshToken for a token the session never
+// issued, and the session with errRefreshTokenReused for one exchanged
+// before the grace period.
+func (s *SessionService) rotateRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash, ip, userAgent string) (repositories.Session, error) {
+	for attempt := 0; attempt < refreshRetries; attempt++ {
+		now := time.Now()
+		session, err := s.repository.GetActiveSession(ctx, id, now)
+		if err != nil {
+			return repositories.Session{}, err
+		}
+		previousHash := session.RefreshHash
+
+		switch used := usedRefreshToken(session, oldHash); {
+		case oldHash == session.RefreshHash:
+			session.UsedRefreshTokens = append(session.UsedRefreshTokens, repositories.UsedRefreshToken{Hash: oldHash, UsedAt: now})
+		case slices.Contains(session.SpareRefreshHashes, oldHash):
+			session.SpareRefreshHashes = slices.DeleteFunc(session.SpareRefreshHashes, func(hash string) bool { return hash == oldHash })
+			session.SpareRefreshHashes = append(session.SpareRefreshHashes, session.RefreshHash)
+			session.UsedRefreshTokens = append(session.UsedRefreshTokens, repositories.UsedRefreshToken{Hash: oldHash, UsedAt: now})
+		case used != nil && now.Sub(used.UsedAt) <= refreshGracePeriod:
+			// Another tab exchanged the same token a moment ago. Its new
+			// token must keep working too.
+			session.SpareRefreshHashes = append(session.SpareRefreshHashes, session.RefreshHash)
+		case used != nil:
+			return session, errRefreshTokenReused
+		default:
+			return repositories.Session{}, ErrInvalidRefreshToken
+		}
+
+		session.RefreshHash = newHash
+		if len(session.SpareRefreshHashes) > maxSpareRefreshTokens {
+			session.SpareRefreshHashes = session.SpareRefreshHashes[len(session.SpareRefreshHashes)-maxSpareRefreshTokens:]
+		}
+		if len(session.UsedRefreshTokens) > maxUsedRefreshTokens {
+			session.UsedRefreshTokens = session.UsedRefreshTokens[len(session.UsedRefreshTokens)-maxUsedRefreshTokens:]
+		}
+		session.LastSeenAt = now
+		session.ExpiresAt = now.Add(s.refreshTTL)
+		session.IP = ip
+		session.UserAgent = userAgent
+
+		updated, err := s.repository.UpdateRefreshTokens(ctx, session, previousHash, now)
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			// Refreshed or revoked since we read it: look again.
+			continue
+		}
+		return updated, err
+	}
+	return repositories.Session{}, ErrInvalidRefreshToken
+}
+
+func usedRefreshToken(session repositories.Session, hash string) *repositories.UsedRefreshToken {
+	for i := range session.UsedRefreshTokens {
+		if session.UsedRefreshTokens[i].Hash == hash {
+			return &session.UsedRefreshTokens[i]
+		}
+	}
+	return nil
+}
+
+// Authenticate checks an access token and that its session is still
+// active, recording the activity.
+func (s *SessionService) Authenticate(ctx context.Context, accessToken, ip string) (AccessClaims, error) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+	email, emailOK := claims["email"].(string)
+	role, roleOK := claims["role"].(string)
+	sid, sidOK := claims["sid"].(string)
+	if !emailOK || !roleOK || !sidOK {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+	sessionID, err := primitive.ObjectIDFromHex(sid)
+	if err != nil {
+		return AccessClaims{}, ErrInvalidAccessToken
+	}
+
+	if _, err := s.repository.TouchSession(ctx, sessionID, ip, time.Now()); err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return AccessClaims{}, ErrInvalidAccessToken
+		}
+		return AccessClaims{}, err
+	}
+	return AccessClaims{Email: email, Role: repositories.AdminRole(role), SessionID: sessionID}, nil
+}
+
+// Logout ends one of email's sessions.
+func (s *SessionService) Logout(ctx context.Context, email string, sessionID primitive.ObjectID) error {
+	return s.repository.RevokeSession(ctx, sessionID, email, "logout")
+}
+
+// LogoutAll ends every session of email, signing them out on all devices.
+func (s *SessionService) LogoutAll(ctx context.Context, email string) (int64, error) {
+	return s.repository.RevokeSessions(ctx, email, "logout all")
+}
+
+func (s *SessionService) GetSessions(ctx context.Context, email string) ([]repositories.Session, error) {
+	return s.repository.GetActiveSessions(ctx, email, time.Now())
+}
+
+func (s *SessionService) issueTokens(admin repositories.Admin, sessionID primitive.ObjectID, secret string) (SessionTokens, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": admin.Email,
+		"role":  string(admin.Role),
+		"sid":   sessionID.Hex(),
+		"exp":   time.Now().Add(s.accessTTL).Unix(),
+	})
+	accessToken, err := token.SignedString(s.secret)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	return SessionTokens{
+		AccessToken:  accessToken,
+		RefreshToken: sessionID.Hex() + "." + secret,
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// newRefreshSecret returns the random part of a refresh token. Refresh
+// tokens are "<session id>.<secret>" and only a hash of the secret is
+// stored.
+func newRefreshSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func parseRefreshToken(token string) (primitive.ObjectID, string, bool) {
+	sid, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return primitive.NilObjectID, "", false
+	}
+	id, err := primitive.ObjectIDFromHex(sid)
+	if err != nil {
+		return primitive.NilObjectID, "", false
+	}
+	return id, secret, true
+}
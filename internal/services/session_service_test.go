@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySessionRepository is an in-memory SessionRepository for tests. It
+// is safe for concurrent use.
+type memorySessionRepository struct {
+	repositories.SessionRepository
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]*repositories.Session
+}
+
+func newMemorySessionRepository() *memorySessionRepository {
+	return &memorySessionRepository{sessions: map[primitive.ObjectID]*repositories.Session{}}
+}
+
+func (r *memorySessionRepository) active(id primitive.ObjectID, now time.Time) *repositories.Session {
+	session := r.sessions[id]
+	if session == nil || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return nil
+	}
+	return session
+}
+
+func (r *memorySessionRepository) CreateSession(_ context.Context, session *repositories.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = primitive.NewObjectID()
+	stored := *session
+	r.sessions[session.ID] = &stored
+	return nil
+}
+
+func (r *memorySessionRepository) TouchSession(_ context.Context, id primitive.ObjectID, ip string, now time.Time) (repositories.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session := r.active(id, now)
+	if session == nil {
+		return repositories.Session{}, repositories.ErrSessionNotFound
+	}
+	session.LastSeenAt, session.IP = now, ip
+	return *session, nil
+}
+
+func (r *memorySessionRepository) GetActiveSession(_ context.Context, id primitive.ObjectID, now time.Time) (repositories.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session := r.active(id, now)
+	if session == nil {
+		return repositories.Session{}, repositories.ErrSessionNotFound
+	}
+	return *session, nil
+}
+
+func (r *memorySessionRepository) UpdateRefreshTokens(_ context.Context, session repositories.Session, previousHash string, now time.Time) (repositories.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.active(session.ID, now)
+	if stored == nil || stored.RefreshHash != previousHash {
+		return repositories.Session{}, repositories.ErrSessionNotFound
+	}
+	stored.RefreshHash = session.RefreshHash
+	stored.SpareRefreshHashes = append([]string(nil), session.SpareRefreshHashes...)
+	stored.UsedRefreshTokens = append([]repositories.UsedRefreshToken(nil), session.UsedRefreshTokens...)
+	stored.LastSeenAt, stored.ExpiresAt = session.LastSeenAt, session.ExpiresAt
+	stored.IP, stored.UserAgent = session.IP, session.UserAgent
+	return *stored, nil
+}
+
+// ageUsedRefreshTokens makes every exchanged refresh token look d older.
+func (r *memorySessionRepository) ageUsedRefreshTokens(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		for i := range session.UsedRefreshTokens {
+			session.UsedRefreshTokens[i].UsedAt = session.UsedRefreshTokens[i].UsedAt.Add(-d)
+		}
+	}
+}
+
+func (r *memorySessionRepository) RevokeSession(_ context.Context, id primitive.ObjectID, email, _ string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session := r.active(id, time.Now())
+	if session == nil || session.Email != email {
+		return repositories.ErrSessionNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	return nil
+}
+
+func (r *memorySessionRepository) RevokeSessions(_ context.Context, email, _ string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var revoked int64
+	for _, session := range r.sessions {
+		if session.Email == email && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (r *memorySessionRepository) GetActiveSessions(_ context.Context, email string, now time.Time) ([]repositories.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []repositories.Session
+	for id := range r.sessions {
+		if session := r.active(id, now); session != nil && session.Email == email {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memoryAdminRepository) GetAdmin(_ context.Context, email string) (repositories.Admin, error) {
+	admin, ok := r.admins[email]
+	if !ok {
+		return repositories.Admin{}, repositories.ErrAdminNotFound
+	}
+	return admin, nil
+}
+
+func newTestSessionService() (*SessionService, *memoryAdminRepository) {
+	admins := newMemoryAdminRepository(repositories.Admin{Email: "admin@example.com", Role: repositories.AdminRoleEditor})
+	return NewSessionService(newMemorySessionRepository(), admins, &config.Config{
+		JWTSecret:       "test-secret",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}), admins
+}
+
+func TestSessionRefreshRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	s, admins := newTestSessionService()
+
+	tokens, err := s.StartSession(ctx, admins.admins["admin@example.com"], "203.0.113.1", "test")
+	require.NoError(t, err)
+	claims, err := s.Authenticate(ctx, tokens.AccessToken, "203.0.113.1")
+	require.NoError(t, err)
+	assert.Equal(t, repositories.AdminRoleEditor, claims.Role)
+
+	refreshed, err := s.Refresh(ctx, tokens.RefreshToken, "203.0.113.1", "test")
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	// Once the grace period is over, replaying the old refresh token ends
+	// the session for everyone.
+	s.repository.(*memorySessionRepository).ageUsedRefreshTokens(time.Minute)
+	_, err = s.Refresh(ctx, tokens.RefreshToken, "198.51.100.7", "attacker")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = s.Refresh(ctx, refreshed.RefreshToken, "203.0.113.1", "test")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = s.Authenticate(ctx, refreshed.AccessToken, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestSessionLogout(t *testing.T) {
+	ctx := context.Background()
+	s, admins := newTestSessionService()
+	admin := admins.admins["admin@example.com"]
+
+	laptop, err := s.StartSession(ctx, admin, "203.0.113.1", "laptop")
+	require.NoError(t, err)
+	phone, err := s.StartSession(ctx, admin, "203.0.113.2", "phone")
+	require.NoError(t, err)
+
+	claims, err := s.Authenticate(ctx, laptop.AccessToken, "203.0.113.1")
+	require.NoError(t, err)
+	require.NoError(t, s.Logout(ctx, admin.Email, claims.SessionID))
+	_, err = s.Authenticate(ctx, laptop.AccessToken, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	sessions, err := s.GetSessions(ctx, admin.Email)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "phone", sessions[0].UserAgent)
+
+	revoked, err := s.LogoutAll(ctx, admin.Email)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, revoked)
+	_, err = s.Authenticate(ctx, phone.AccessToken, "203.0.113.2")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestSessionRefreshExtendsExpiry(t *testing.T) {
+	ctx := context.Background()
+	s, admins := newTestSessionService()
+	sessions := s.repository.(*memorySessionRepository)
+
+	tokens, err := s.StartSession(ctx, admins.admins["admin@example.com"], "203.0.113.1", "test")
+	require.NoError(t, err)
+	for _, session := range sessions.sessions {
+		session.ExpiresAt = time.Now().Add(time.Minute)
+	}
+
+	_, err = s.Refresh(ctx, tokens.RefreshToken, "203.0.113.1", "test")
+	require.NoError(t, err)
+	for _, session := range sessions.sessions {
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), session.ExpiresAt, 5*time.Second)
+	}
+}
+
+func TestSessionRefreshWithWrongSecretKeepsSession(t *testing.T) {
+	ctx := context.Background()
+	s, admins := newTestSessionService()
+
+	tokens, err := s.StartSession(ctx, admins.admins["admin@example.com"], "203.0.113.1", "test")
+	require.NoError(t, err)
+	sid, _, _ := strings.Cut(tokens.RefreshToken, ".")
+
+	_, err = s.Refresh(ctx, sid+".not-the-secret", "198.51.100.7", "attacker")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// A token the session never issued doesn't end it.
+	_, err = s.Authenticate(ctx, tokens.AccessToken, "203.0.113.1")
+	require.NoError(t, err)
+	_, err = s.Refresh(ctx, tokens.RefreshToken, "203.0.113.1", "test")
+	assert.NoError(t, err)
+}
+
+func TestSessionConcurrentRefreshes(t *testing.T) {
+	ctx := context.Background()
+	s, admins := newTestSessionService()
+
+	tokens, err := s.StartSession(ctx, admins.admins["admin@example.com"], "203.0.113.1", "test")
+	require.NoError(t, err)
+
+	// Two tabs refresh with the same token at once.
+	const tabs = 2
+	refreshed := make([]SessionTokens, tabs)
+	errs := make([]error, tabs)
+	var wg sync.WaitGroup
+	for i := 0; i < tabs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			refreshed[i], errs[i] = s.Refresh(ctx, tokens.RefreshToken, "203.0.113.1", "test")
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < tabs; i++ {
+		require.NoError(t, errs[i])
+	}
+	assert.NotEqual(t, refreshed[0].RefreshToken, refreshed[1].RefreshToken)
+
+	// Whichever token a tab kept still works after the grace period.
+	s.repository.(*memorySessionRepository).ageUsedRefreshTokens(time.Minute)
+	for i := 0; i < tabs; i++ {
+		_, err := s.Refresh(ctx, refreshed[i].RefreshToken, "203.0.113.1", "test")
+		assert.NoError(t, err, i)
+	}
+}
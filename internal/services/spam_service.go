@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	formTokenPurpose = "contact-form"
+	// formTokenMaxAge is how long a form can sit open before its token is
+	// treated as stale.
+	formTokenMaxAge = 24 * time.Hour
+)
+
+// defaultDisposableDomains are throwaway email providers commonly used by
+// spammers. SPAM_DISPOSABLE_DOMAINS adds to the list.
+var defaultDisposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"sharklasers.com",
+	"temp-mail.org",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.|\[url`)
+
+// Submission is a public form submission as seen by the spam checks.
+type Submission struct {
+	Name       string
+	Email      string
+	Message    string
+	Honeypot   string
+	FormToken  string
+	IP         string
+	UserAgent  string
+	ReceivedAt time.Time
+}
+
+// SpamSignal is one reason a submission looks like spam and how much it
+// counts towards the spam score.
+type SpamSignal struct {
+	Score  int
+	Reason string
+}
+
+// SpamCheck inspects a submission and reports any spam signals. Checks are
+// pluggable: register extra ones with SpamService.AddCheck.
+type SpamCheck interface {
+	Check(ctx context.Context, submission Submission) []SpamSignal
+}
+
+// SpamCheckFunc adapts a function to SpamCheck.
+type SpamCheckFunc func(ctx context.Context, submission Submission) []SpamSignal
+
+func (f SpamCheckFunc) Check(ctx context.Context, submission Submission) []SpamSignal {
+	return f(ctx, submission)
+}
+
+// SpamVerdict is the combined result of every check. Spam submissions are
+// quarantined rather than rejected.
+type SpamVerdict struct {
+	Score   int
+	Reasons []string
+	Spam    bool
+}
+
+type SpamService struct {
+	formTokens        repositories.FormTokenRepository
+	secret            []byte
+	minSubmitTime     time.Duration
+	threshold         int
+	maxLinks          int
+	blockedKeywords   []string
+	disposableDomains map[string]bool
+
+	mu     sync.RWMutex
+	checks []SpamCheck
+}
+
+func NewSpamService(formTokens repositories.FormTokenRepository, cfg *config.Config) *SpamService {
+	s := &SpamService{
+		formTokens:        formTokens,
+		secret:            []byte(cfg.JWTSecret),
+		minSubmitTime:     cfg.SpamMinSubmitTime,
+		threshold:         cfg.SpamScoreThreshold,
+		maxLinks:          cfg.SpamMaxLinks,
+		disposableDomains: map[string]bool{},
+	}
+	for _, keyword := range cfg.SpamBlockedKeywords {
+		s.blockedKeywords = append(s.blockedKeywords, strings.ToLower(keyword))
+	}
+	for _, domain := range append(defaultDisposableDomains, cfg.SpamDisposableDomains...) {
+		s.disposableDomains[strings.ToLower(domain)] = true
+	}
+
+	s.checks = []SpamCheck{
+		SpamCheckFunc(s.checkHoneypot),
+		SpamCheckFunc(s.checkFormToken),
+		SpamCheckFunc(s.checkLinks),
+		SpamCheckFunc(s.checkKeywords),
+		SpamCheckFunc(s.checkDisposableDomain),
+	}
+	return s
+}
+
+// AddCheck registers an additional spam check, such as a call to an
+// external scoring service.
+func (s *SpamService) AddCheck(check SpamCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, check)
+}
+
+// IssueFormToken returns a signed token recording when the form was
+// served. Submitting it back lets Evaluate tell how long the visitor took to
+// fill in the form. Each token can only be submitted once.
+func (s *SpamService) IssueFormToken(now time.Time) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate form token: %v", err)
+	}
+	payload := strconv.FormatInt(now.Unix(), 10) + ":" + hex.EncodeToString(nonce)
+	return signToken(s.secret, formTokenPurpose, payload), nil
+}
+
+// Evaluate runs every check and adds up the signals.
+func (s *SpamService) Evaluate(ctx context.Context, submission Submission) SpamVerdict {
+	if submission.ReceivedAt.IsZero() {
+		submission.ReceivedAt = time.Now()
+	}
+
+	s.mu.RLock()
+	checks := append([]SpamCheck(nil), s.checks...)
+	s.mu.RUnlock()
+
+	var verdict SpamVerdict
+	for _, check := range checks {
+		for _, signal := range check.Check(ctx, submission) {
+			verdict.Score += signal.Score
+			verdict.Reasons = append(verdict.Reasons, signal.Reason)
+		}
+	}
+	verdict.Spam = verdict.Score >= s.threshold
+	return verdict
+}
+
+// checkHoneypot flags submissions that filled in the hidden field real
+// visitors never see.
+func (s *SpamService) checkHoneypot(_ context.Context, submission Submission) []SpamSignal {
+	if strings.TrimSpace(submission.Honeypot) != "" {
+		return []SpamSignal{{Score: 10, Reason: "honeypot field was filled in"}}
+	}
+	return nil
+}
+
+func (s *SpamService) checkFormToken(ctx context.Context, submission Submission) []SpamSignal {
+	if submission.FormToken == "" {
+		return []SpamSignal{{Score: 2, Reason: "form token is missing"}}
+	}
+
+	payload, ok := verifyToken(s.secret, formTokenPurpose, submission.FormToken)
+	if !ok {
+		return []SpamSignal{{Score: 5, Reason: "form token is invalid"}}
+	}
+	issued, nonce, _ := strings.Cut(payload, ":")
+	issuedUnix, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil || nonce == "" {
+		return []SpamSignal{{Score: 5, Reason: "form token is invalid"}}
+	}
+
+	// A token that can't be checked for reuse is let through rather than
+	// quarantining every submission while the database is unavailable.
+	unused, err := s.formTokens.UseFormToken(ctx, nonce, time.Unix(issuedUnix, 0).Add(formTokenMaxAge))
+	if err != nil {
+		log.Printf("Failed to record form token use: %v", err)
+	} else if !unused {
+		return []SpamSignal{{Score: 5, Reason: "form token was already used"}}
+	}
+
+	elapsed := submission.ReceivedAt.Sub(time.Unix(issuedUnix, 0))
+	switch {
+	case elapsed < s.minSubmitTime:
+		return []SpamSignal{{Score: 5, Reason: fmt.Sprintf("form submitted %s after loading", elapsed.Round(time.Millisecond))}}
+	case elapsed > formTokenMaxAge:
+		return []SpamSignal{{Score: 2, Reason: "form token has expired"}}
+	}
+	return nil
+}
+
+func (s *SpamService) checkLinks(_ context.Context, submission Submission) []SpamSignal {
+	links := len(linkPattern.FindAllStringIndex(submission.Message, -1)) +
+		len(linkPattern.FindAllStringIndex(submission.Name, -1))
+	if links > s.maxLinks {
+		return []SpamSignal{{Score: 3 + links - s.maxLinks, Reason: fmt.Sprintf("contains %d links", links)}}
+	}
+	return nil
+}
+
+func (s *SpamService) checkKeywords(_ context.Context, submission Submission) []SpamSignal {
+	text := strings.ToLower(submission.Name + " " + submission.Message)
+	var signals []SpamSignal
+	for _, keyword := range s.blockedKeywords {
+		if strings.Contains(text, keyword) {
+			signals = append(signals, SpamSignal{Score: 3, Reason: fmt.Sprintf("contains blocked keyword %q", keyword)})
+		}
+	}
+	return signals
+}
+
+func (s *SpamService) checkDisposableDomain(_ context.Context, submission Submission) []SpamSignal {
+	_, domain, ok := strings.Cut(normalizeEmail(submission.Email), "@")
+	if ok && s.disposableDomains[domain] {
+		return []SpamSignal{{Score: 3, Reason: fmt.Sprintf("uses disposable email domain %s", domain)}}
+	}
+	return nil
+}
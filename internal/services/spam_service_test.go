@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"chanterelle/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFormTokenRepository is an in-memory FormTokenRepository for tests.
+type memoryFormTokenRepository struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func (r *memoryFormTokenRepository) UseFormToken(_ context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.used[nonce]; ok {
+		return false, nil
+	}
+	r.used[nonce] = expiresAt
+	return true, nil
+}
+
+func newTestSpamService() *SpamService {
+	return NewSpamService(&memoryFormTokenRepository{used: map[string]time.Time{}}, &config.Config{
+		JWTSecret:           "test-secret",
+		SpamMinSubmitTime:   3 * time.Second,
+		SpamScoreThreshold:  5,
+		SpamMaxLinks:        2,
+		SpamBlockedKeywords: []string{"Crypto"},
+	})
+}
+
+func TestSpamServiceEvaluate(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSpamService()
+	loaded := time.Now().Add(-time.Minute)
+	// Every submission needs its own token, as tokens can only be used once.
+	issue := func() string {
+		token, err := s.IssueFormToken(loaded)
+		require.NoError(t, err)
+		return token
+	}
+
+	genuine := Submission{
+		Name:       "Alex",
+		Email:      "alex@example.com",
+		Message:    "Loved the show, see https://example.com/photos",
+		FormToken:  issue(),
+		ReceivedAt: time.Now(),
+	}
+	verdict := s.Evaluate(ctx, genuine)
+	assert.False(t, verdict.Spam)
+	assert.Zero(t, verdict.Score)
+
+	honeypot := genuine
+	honeypot.FormToken = issue()
+	honeypot.Honeypot = "http://spam.example"
+	assert.True(t, s.Evaluate(ctx, honeypot).Spam)
+
+	tooFast := genuine
+	tooFast.FormToken = issue()
+	tooFast.ReceivedAt = loaded.Add(time.Second)
+	assert.True(t, s.Evaluate(ctx, tooFast).Spam)
+
+	forged := genuine
+	forged.FormToken = issue() + "x"
+	assert.True(t, s.Evaluate(ctx, forged).Spam)
+
+	heuristics := genuine
+	heuristics.FormToken = issue()
+	heuristics.Email = "bot@Mailinator.com"
+	heuristics.Message = "Buy CRYPTO now"
+	verdict = s.Evaluate(ctx, heuristics)
+	assert.True(t, verdict.Spam)
+	assert.Len(t, verdict.Reasons, 2)
+}
+
+func TestSpamServiceAddCheck(t *testing.T) {
+	s := newTestSpamService()
+	token, err := s.IssueFormToken(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	s.AddCheck(SpamCheckFunc(func(_ context.Context, submission Submission) []SpamSignal {
+		if submission.IP == "203.0.113.9" {
+			return []SpamSignal{{Score: 5, Reason: "blocklisted IP"}}
+		}
+		return nil
+	}))
+
+	verdict := s.Evaluate(context.Background(), Submission{
+		Name:      "Alex",
+		Email:     "alex@example.com",
+		FormToken: token,
+		IP:        "203.0.113.9",
+	})
+	assert.True(t, verdict.Spam)
+	assert.Equal(t, []string{"blocklisted IP"}, verdict.Reasons)
+}
+
+func TestSpamServiceRejectsReusedFormToken(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSpamService()
+	token, err := s.IssueFormToken(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	submission := Submission{Name: "Alex", Email: "alex@example.com", FormToken: token}
+
+	// Bots replaying one token in parallel get through at most once.
+	var wg sync.WaitGroup
+	verdicts := make([]SpamVerdict, 10)
+	for i := range verdicts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			verdicts[i] = s.Evaluate(ctx, submission)
+		}(i)
+	}
+	wg.Wait()
+
+	genuine := 0
+	for _, verdict := range verdicts {
+		if !verdict.Spam {
+			genuine++
+			continue
+		}
+		assert.Equal(t, []string{"form token was already used"}, verdict.Reasons)
+	}
+	assert.Equal(t, 1, genuine)
+}
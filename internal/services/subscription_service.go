@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/models"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	subscriptionTokenPurpose = "subscription-confirm"
+	// confirmationConsentText is recorded when a confirmation link is
+	// followed. It leaves out the address so erasing it leaves none behind.
+	confirmationConsentText = "Confirmed by following the link in the confirmation email"
+)
+
+var ErrInvalidSubscriptionToken = errors.New("invalid confirmation link")
+
+// SubscriptionService decides how a contact joins the mailing list. With
+// double opt-in on, the contact is emailed a signed confirmation link and
+// only subscribed to Mailchimp once they follow it; otherwise they are
+// queued for Mailchimp straight away.
+type SubscriptionService struct {
+	repository          repositories.SubscriptionRepository
+	mailchimpSync       *MailchimpSyncService
+	notificationService *NotificationService
+	consentService      *ConsentService
+	secret              []byte
+	doubleOptIn         bool
+	confirmWindow       time.Duration
+	frontendURL         string
+}
+
+func NewSubscriptionService(repository repositories.SubscriptionRepository, mailchimpSync *MailchimpSyncService, notificationService *NotificationService, consentService *ConsentService, cfg *config.Config) *SubscriptionService {
+	return &SubscriptionService{
+		repository:          repository,
+		mailchimpSync:       mailchimpSync,
+		notificationService: notificationService,
+		consentService:      consentService,
+		secret:              []byte(cfg.JWTSecret),
+		doubleOptIn:         cfg.DoubleOptIn,
+		confirmWindow:       cfg.SubscriptionConfirmWindow,
+		frontendURL:         strings.TrimRight(cfg.FrontendURL, "/"),
+	}
+}
+
+// Subscribe starts the signup for a contact who agreed on the contact form,
+// recording their consent first. People who have already confirmed a
+// subscription are not asked again.
+func (s *SubscriptionService) Subscribe(ctx context.Context, contact *repositories.Contact, consent SignupConsent) error {
+	if err := s.consentService.RecordConsent(ctx, contact, repositories.ConsentSignup,
+		ConsentSourceContactForm, consent.Text, consent.Evidence); err != nil {
+		return err
+	}
+
+	if !s.doubleOptIn {
+		return s.mailchimpSync.Queue(ctx, contact.ID)
+	}
+
+	email := normalizeEmail(contact.Email)
+	subscribed, err := s.repository.IsSubscribed(ctx, email)
+	if err != nil {
+		return err
+	}
+	if subscribed {
+		return s.mailchimpSync.Queue(ctx, contact.ID)
+	}
+
+	expiresAt := time.Now().Add(s.confirmWindow)
+	subscription := repositories.Subscription{
+		Email:     email,
+		Name:      contact.Name,
+		ContactID: contact.ID,
+		Status:    repositories.SubscriptionPending,
+		ExpiresAt: &expiresAt,
+	}
+	if err := s.repository.CreateSubscription(ctx, &subscription); err != nil {
+		return err
+	}
+
+	token := signToken(s.secret, subscriptionTokenPurpose, subscription.ID.Hex())
+	link := s.frontendURL + "/subscribe/confirm?token=" + url.QueryEscape(token)
+	return s.notificationService.SendSubscriptionConfirmation(&models.Contact{
+		Name:  contact.Name,
+		Email: contact.Email,
+	}, link, expiresAt)
+}
+
+// Confirm confirms the subscription a confirmation link was issued for,
+// records the confirmation as consent and queues the contact for Mailchimp.
+// Following the link again is harmless, and finishes a confirmation whose
+// consent record or queueing failed the first time.
+func (s *SubscriptionService) Confirm(ctx context.Context, token string, evidence ConsentEvidence) (repositories.Subscription, error) {
+	payload, ok := verifyToken(s.secret, subscriptionTokenPurpose, token)
+	if !ok {
+		return repositories.Subscription{}, ErrInvalidSubscriptionToken
+	}
+	id, err := primitive.ObjectIDFromHex(payload)
+	if err != nil {
+		return repositories.Subscription{}, ErrInvalidSubscriptionToken
+	}
+
+	subscription, _, err := s.repository.ConfirmSubscription(ctx, id, time.Now())
+	if errors.Is(err, repositories.ErrSubscriptionNotFound) {
+		// Expired pending subscriptions are removed, so a missing one has
+		// almost certainly expired.
+		return repositories.Subscription{}, repositories.ErrSubscriptionExpired
+	}
+	if err != nil {
+		return repositories.Subscription{}, err
+	}
+
+	contact := &repositories.Contact{ID: subscription.ContactID, Email: subscription.Email}
+	recorded, err := s.consentService.HasConsent(ctx, contact, repositories.ConsentConfirmation)
+	if err != nil {
+		return repositories.Subscription{}, err
+	}
+	if !recorded {
+		if err := s.consentService.RecordConsent(ctx, contact, repositories.ConsentConfirmation,
+			ConsentSourceContactForm, confirmationConsentText, evidence); err != nil {
+			return repositories.Subscription{}, err
+		}
+	}
+	if err := s.mailchimpSync.QueueUnlessSynced(ctx, subscription.ContactID); err != nil {
+		return repositories.Subscription{}, err
+	}
+	return subscription, nil
+}
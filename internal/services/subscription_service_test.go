@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySubscriptionRepository is an in-memory SubscriptionRepository for
+// tests.
+type memorySubscriptionRepository struct {
+	repositories.SubscriptionRepository
+	subscriptions map[primitive.ObjectID]*repositories.Subscription
+}
+
+func (r *memorySubscriptionRepository) ConfirmSubscription(_ context.Context, id primitive.ObjectID, now time.Time) (repositories.Subscription, bool, error) {
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return repositories.Subscription{}, false, repositories.ErrSubscriptionNotFound
+	}
+	if subscription.Status == repositories.SubscriptionConfirmed {
+		return *subscription, false, nil
+	}
+	if !subscription.ExpiresAt.After(now) {
+		return repositories.Subscription{}, false, repositories.ErrSubscriptionExpired
+	}
+	subscription.Status = repositories.SubscriptionConfirmed
+	subscription.ConfirmedAt = &now
+	subscription.ExpiresAt = nil
+	return *subscription, true, nil
+}
+
+// memoryConsentRepository is an in-memory ConsentRepository for tests. err,
+// when set, is returned by the next RecordConsent.
+type memoryConsentRepository struct {
+	repositories.ConsentRepository
+	consents []repositories.Consent
+	err      error
+}
+
+func (r *memoryConsentRepository) RecordConsent(_ context.Context, consent *repositories.Consent) error {
+	if err := r.err; err != nil {
+		r.err = nil
+		return err
+	}
+	r.consents = append(r.consents, *consent)
+	return nil
+}
+
+func (r *memoryConsentRepository) GetConsentsByEmails(_ context.Context, emails []string) ([]repositories.Consent, error) {
+	consents := []repositories.Consent{}
+	for _, consent := range r.consents {
+		for _, email := range emails {
+			if consent.Email == email {
+				consents = append(consents, consent)
+			}
+		}
+	}
+	return consents, nil
+}
+
+type subscriptionTest struct {
+	service       *SubscriptionService
+	subscriptions *memorySubscriptionRepository
+	consents      *memoryConsentRepository
+	contact       *repositories.Contact
+}
+
+// newSubscriptionTest returns a SubscriptionService with one pending
+// subscription expiring at expiresAt, and its confirmation token.
+func newSubscriptionTest(expiresAt time.Time) (*subscriptionTest, string) {
+	cfg := &config.Config{JWTSecret: "test-secret", DoubleOptIn: true}
+	contact := &repositories.Contact{ID: primitive.NewObjectID(), Name: "Pat Fan", Email: "fan@example.com"}
+	subscription := &repositories.Subscription{
+		ID:        primitive.NewObjectID(),
+		Email:     contact.Email,
+		ContactID: contact.ID,
+		Status:    repositories.SubscriptionPending,
+		ExpiresAt: &expiresAt,
+	}
+	subscriptions := &memorySubscriptionRepository{
+		subscriptions: map[primitive.ObjectID]*repositories.Subscription{subscription.ID: subscription},
+	}
+	consents := &memoryConsentRepository{}
+	mailchimpSync := NewMailchimpSyncService(&memoryContactRepository{contacts: []*repositories.Contact{contact}}, nil, cfg)
+	s := NewSubscriptionService(subscriptions, mailchimpSync, nil, NewConsentService(consents, nil, cfg), cfg)
+
+	token := signToken(s.secret, subscriptionTokenPurpose, subscription.ID.Hex())
+	return &subscriptionTest{service: s, subscriptions: subscriptions, consents: consents, contact: contact}, token
+}
+
+func TestConfirmSubscriptionTwice(t *testing.T) {
+	ctx := context.Background()
+	st, token := newSubscriptionTest(time.Now().Add(time.Hour))
+
+	subscription, err := st.service.Confirm(ctx, token, ConsentEvidence{IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.Equal(t, repositories.SubscriptionConfirmed, subscription.Status)
+	require.Len(t, st.consents.consents, 1)
+	assert.Equal(t, repositories.ConsentConfirmation, st.consents.consents[0].Event)
+	assert.Equal(t, "192.0.2.1", st.consents.consents[0].IP)
+	require.NotNil(t, st.contact.MailchimpSync)
+	assert.Equal(t, repositories.MailchimpSyncPending, st.contact.MailchimpSync.Status)
+
+	// Following the link again neither records consent again nor disturbs
+	// a sync that is under way or done.
+	st.contact.MailchimpSync.Status = repositories.MailchimpSyncSynced
+	again, err := st.service.Confirm(ctx, token, ConsentEvidence{IP: "192.0.2.2"})
+	require.NoError(t, err)
+	assert.Equal(t, subscription, again)
+	assert.Len(t, st.consents.consents, 1)
+	assert.Equal(t, repositories.MailchimpSyncSynced, st.contact.MailchimpSync.Status)
+}
+
+func TestConfirmSubscriptionFinishesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	st, token := newSubscriptionTest(time.Now().Add(time.Hour))
+	st.consents.err = errors.New("database unavailable")
+
+	_, err := st.service.Confirm(ctx, token, ConsentEvidence{})
+	require.Error(t, err)
+	assert.Empty(t, st.consents.consents)
+	assert.Nil(t, st.contact.MailchimpSync)
+
+	// The subscription is confirmed by now, but following the link again
+	// still records consent and queues the contact.
+	_, err = st.service.Confirm(ctx, token, ConsentEvidence{})
+	require.NoError(t, err)
+	assert.Len(t, st.consents.consents, 1)
+	require.NotNil(t, st.contact.MailchimpSync)
+	assert.Equal(t, repositories.MailchimpSyncPending, st.contact.MailchimpSync.Status)
+}
+
+func TestConfirmSubscriptionExpired(t *testing.T) {
+	ctx := context.Background()
+	st, token := newSubscriptionTest(time.Now().Add(-time.Minute))
+
+	_, err := st.service.Confirm(ctx, token, ConsentEvidence{})
+	assert.ErrorIs(t, err, repositories.ErrSubscriptionExpired)
+
+	// Expired subscriptions are soon removed, which reads the same.
+	st.subscriptions.subscriptions = map[primitive.ObjectID]*repositories.Subscription{}
+	_, err = st.service.Confirm(ctx, token, ConsentEvidence{})
+	assert.ErrorIs(t, err, repositories.ErrSubscriptionExpired)
+
+	_, err = st.service.Confirm(ctx, token+"x", ConsentEvidence{})
+	assert.ErrorIs(t, err, ErrInvalidSubscriptionToken)
+
+	assert.Empty(t, st.consents.consents)
+	assert.Nil(t, st.contact.MailchimpSync)
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+)
+
+const (
+	totpIssuer        = "Chanterelle"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidTOTPCode     = errors.New("invalid authenticator code")
+	ErrTOTPNotEnrolled     = errors.New("no authenticator is set up")
+	ErrTOTPEnrollmentEmpty = errors.New("start authenticator enrollment first")
+	ErrInvalidLoginPolicy  = errors.New("policy must be email, either or totp")
+	ErrTOTPCodeRequired    = errors.New("enter a code from your authenticator or a recovery code")
+)
+
+// TOTPEnrollment is what an authenticator app needs to be set up. URI is
+// usually shown as a QR code; Secret can be typed in instead.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPService lets admins sign in with an authenticator app (RFC 6238) as
+// well as, or instead of, emailed codes.
+type TOTPService struct {
+	admins       repositories.AdminRepository
+	verification *VerificationService
+	key          []byte
+	secret       []byte
+}
+
+func NewTOTPService(admins repositories.AdminRepository, verification *VerificationService, cfg *config.Config) *TOTPService {
+	return &TOTPService{
+		admins:       admins,
+		verification: verification,
+		key:          deriveKey(cfg.JWTSecret, "totp-secret"),
+		secret:       []byte(cfg.JWTSecret),
+	}
+}
+
+// BeginEnrollment generates a new authenticator secret for email. It only
+// takes effect once ConfirmEnrollment sees a code from it.
+func (s *TOTPService) BeginEnrollment(ctx context.Context, email string) (TOTPEnrollment, error) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	sealed, err := sealSecret(s.key, secret)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if err := s.admins.SetPendingTOTP(ctx, email, sealed); err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{
+		Secret: totpEncoding.EncodeToString(secret),
+		URI:    totpProvisioningURI(totpIssuer, email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment turns on the pending authenticator once the admin
+// proves it works, and returns one-time recovery codes. They are shown
+// only now; just their hashes are kept. Replacing an authenticator that is
+// already on also needs currentCode, a code from it or a recovery code,
+// entered from ip.
+func (s *TOTPService) ConfirmEnrollment(ctx context.Context, email, code, currentCode, ip string) ([]string, error) {
+	admin, err := s.admins.GetAdmin(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TOTPPendingSecret == "" {
+		return nil, ErrTOTPEnrollmentEmpty
+	}
+	secret, err := openSecret(s.key, admin.TOTPPendingSecret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+	if err := s.verifyIfEnabled(ctx, admin, currentCode, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.newRecoveryCodes(email)
+	if err != nil {
+		return nil, err
+	}
+	policy := admin.LoginPolicy
+	if !policy.AllowsTOTP() {
+		policy = repositories.LoginPolicyEither
+	}
+	if err := s.admins.EnableTOTP(ctx, email, admin.TOTPPendingSecret, step, hashes, policy); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the authenticator; the admin signs in with emailed codes
+// again. code must be a current authenticator or recovery code, entered
+// from ip.
+func (s *TOTPService) Disable(ctx context.Context, email, code, ip string) error {
+	admin, err := s.admins.GetAdmin(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyIfEnabled(ctx, admin, code, ip); err != nil {
+		return err
+	}
+	return s.admins.DisableTOTP(ctx, email)
+}
+
+// SetPolicy chooses which codes email may sign in with. Policies using an
+// authenticator need one to be set up, and once one is, changing the
+// policy needs code, a current authenticator or recovery code, entered
+// from ip.
+func (s *TOTPService) SetPolicy(ctx context.Context, email string, policy repositories.LoginPolicy, code, ip string) error {
+	if !policy.IsValid() {
+		return ErrInvalidLoginPolicy
+	}
+	admin, err := s.admins.GetAdmin(ctx, email)
+	if err != nil {
+		return err
+	}
+	if policy.AllowsTOTP() && !admin.TOTPEnabled() {
+		return ErrTOTPNotEnrolled
+	}
+	if err := s.verifyIfEnabled(ctx, admin, code, ip); err != nil {
+		return err
+	}
+	return s.admins.SetLoginPolicy(ctx, email, policy)
+}
+
+// verifyIfEnabled asks for a second factor before changing an
+// authenticator that is on. It is checked under the same lockouts as
+// signing in, so a stolen session can't guess its way past it.
+func (s *TOTPService) verifyIfEnabled(ctx context.Context, admin repositories.Admin, code, ip string) error {
+	if !admin.TOTPEnabled() {
+		return nil
+	}
+	if strings.TrimSpace(code) == "" {
+		return ErrTOTPCodeRequired
+	}
+	return s.verification.VerifyAuthenticatorCode(ctx, admin.Email, ip, func(ctx context.Context) error {
+		return s.Verify(ctx, admin, code)
+	})
+}
+
+// Verify checks an authenticator code, or failing that a recovery code,
+// for admin. Each authenticator code and recovery code works only once.
+func (s *TOTPService) Verify(ctx context.Context, admin repositories.Admin, code string) error {
+	if !admin.TOTPEnabled() {
+		return ErrTOTPNotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		secret, err := openSecret(s.key, admin.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		step, ok := matchTOTP(secret, code, time.Now())
+		if !ok {
+			return ErrInvalidTOTPCode
+		}
+		fresh, err := s.admins.UseTOTPStep(ctx, admin.Email, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	used, err := s.admins.UseRecoveryCode(ctx, admin.Email, s.hashRecoveryCode(admin.Email, code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns recovery codes formatted for reading, like
+// "k3jd-82mf", and their hashes.
+func (s *TOTPService) newRecoveryCodes(email string) ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %v", err)
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, s.hashRecoveryCode(email, raw))
+	}
+	return codes, hashes, nil
+}
+
+func (s *TOTPService) hashRecoveryCode(email, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("recovery\x00" + email + "\x00"))
+	mac.Write([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"testing"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryAdminRepository) SetPendingTOTP(_ context.Context, email, sealedSecret string) error {
+	admin := r.admins[email]
+	admin.TOTPPendingSecret = sealedSecret
+	r.admins[email] = admin
+	return nil
+}
+
+func (r *memoryAdminRepository) EnableTOTP(_ context.Context, email, sealedSecret string, usedStep int64, recoveryCodeHashes []string, policy repositories.LoginPolicy) error {
+	admin := r.admins[email]
+	if admin.TOTPPendingSecret != sealedSecret {
+		return repositories.ErrTOTPEnrollmentChanged
+	}
+	admin.TOTPSecret, admin.TOTPPendingSecret = admin.TOTPPendingSecret, ""
+	admin.TOTPLastStep = usedStep
+	admin.RecoveryCodeHashes = recoveryCodeHashes
+	admin.LoginPolicy = policy
+	r.admins[email] = admin
+	return nil
+}
+
+func (r *memoryAdminRepository) DisableTOTP(_ context.Context, email string) error {
+	admin := r.admins[email]
+	admin.TOTPSecret, admin.TOTPPendingSecret, admin.TOTPLastStep = "", "", 0
+	admin.RecoveryCodeHashes = nil
+	admin.LoginPolicy = repositories.LoginPolicyEmail
+	r.admins[email] = admin
+	return nil
+}
+
+func (r *memoryAdminRepository) SetLoginPolicy(_ context.Context, email string, policy repositories.LoginPolicy) error {
+	admin := r.admins[email]
+	admin.LoginPolicy = policy
+	r.admins[email] = admin
+	return nil
+}
+
+func (r *memoryAdminRepository) UseTOTPStep(_ context.Context, email string, step int64) (bool, error) {
+	admin := r.admins[email]
+	if admin.TOTPLastStep >= step {
+		return false, nil
+	}
+	admin.TOTPLastStep = step
+	r.admins[email] = admin
+	return true, nil
+}
+
+func (r *memoryAdminRepository) UseRecoveryCode(_ context.Context, email, codeHash string) (bool, error) {
+	admin := r.admins[email]
+	for i, hash := range admin.RecoveryCodeHashes {
+		if hash == codeHash {
+			admin.RecoveryCodeHashes = append(admin.RecoveryCodeHashes[:i], admin.RecoveryCodeHashes[i+1:]...)
+			r.admins[email] = admin
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestTOTPEnrollmentAndVerify(t *testing.T) {
+	ctx := context.Background()
+	admins := &memoryAdminRepository{admins: map[string]repositories.Admin{
+		"admin@example.com": {Email: "admin@example.com", Role: repositories.AdminRoleOwner},
+	}}
+	s := NewTOTPService(admins, newTestVerificationService(newMemoryVerificationRepository()), &config.Config{JWTSecret: "test-secret"})
+
+	enrollment, err := s.BeginEnrollment(ctx, "admin@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Chanterelle:admin@example.com?")
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	codeAt := func(t time.Time) string {
+		return hotp(sha1.New, secret, uint64(totpStep(t)), totpDigits)
+	}
+
+	_, err = s.ConfirmEnrollment(ctx, "admin@example.com", "000000", "", "203.0.113.1")
+	if codeAt(time.Now()) != "000000" {
+		assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+	}
+	confirmation := codeAt(time.Now())
+	recovery, err := s.ConfirmEnrollment(ctx, "admin@example.com", confirmation, "", "203.0.113.1")
+	require.NoError(t, err)
+	require.Len(t, recovery, recoveryCodeCount)
+
+	admin := admins.admins["admin@example.com"]
+	assert.True(t, admin.TOTPEnabled())
+	assert.Equal(t, repositories.LoginPolicyEither, admin.LoginPolicy)
+
+	// The code that confirmed enrollment can't also sign in.
+	assert.ErrorIs(t, s.Verify(ctx, admin, confirmation), ErrInvalidTOTPCode)
+
+	// A code is accepted once; replaying it fails.
+	code := codeAt(time.Now().Add(totpPeriod))
+	require.NoError(t, s.Verify(ctx, admin, code))
+	assert.ErrorIs(t, s.Verify(ctx, admins.admins["admin@example.com"], code), ErrInvalidTOTPCode)
+
+	// Recovery codes work once, however they are typed.
+	require.NoError(t, s.Verify(ctx, admin, " "+recovery[0]+" "))
+	assert.ErrorIs(t, s.Verify(ctx, admin, recovery[0]), ErrInvalidTOTPCode)
+	assert.Len(t, admins.admins["admin@example.com"].RecoveryCodeHashes, recoveryCodeCount-1)
+}
+
+func TestTOTPChangesNeedCurrentCode(t *testing.T) {
+	ctx := context.Background()
+	const ip = "203.0.113.1"
+	admins := &memoryAdminRepository{admins: map[string]repositories.Admin{
+		"admin@example.com": {Email: "admin@example.com", Role: repositories.AdminRoleOwner},
+	}}
+	s := NewTOTPService(admins, newTestVerificationService(newMemoryVerificationRepository()), &config.Config{JWTSecret: "test-secret"})
+
+	enroll := func() []byte {
+		enrollment, err := s.BeginEnrollment(ctx, "admin@example.com")
+		require.NoError(t, err)
+		secret, err := totpEncoding.DecodeString(enrollment.Secret)
+		require.NoError(t, err)
+		return secret
+	}
+	codeFor := func(secret []byte) string {
+		return hotp(sha1.New, secret, uint64(totpStep(time.Now())), totpDigits)
+	}
+
+	first := enroll()
+	recovery, err := s.ConfirmEnrollment(ctx, "admin@example.com", codeFor(first), "", ip)
+	require.NoError(t, err)
+	enabled := admins.admins["admin@example.com"].TOTPSecret
+
+	// With an authenticator on, a session alone cannot replace or remove it
+	// or stop it being asked for.
+	second := enroll()
+	_, err = s.ConfirmEnrollment(ctx, "admin@example.com", codeFor(second), "", ip)
+	assert.ErrorIs(t, err, ErrTOTPCodeRequired)
+	assert.ErrorIs(t, s.Disable(ctx, "admin@example.com", "", ip), ErrTOTPCodeRequired)
+	assert.ErrorIs(t, s.Disable(ctx, "admin@example.com", "zzzz-zzzz", ip), ErrInvalidVerificationCode)
+	assert.ErrorIs(t, s.SetPolicy(ctx, "admin@example.com", repositories.LoginPolicyEmail, "", ip), ErrTOTPCodeRequired)
+	admin := admins.admins["admin@example.com"]
+	assert.Equal(t, enabled, admin.TOTPSecret)
+	assert.Equal(t, repositories.LoginPolicyEither, admin.LoginPolicy)
+
+	require.NoError(t, s.SetPolicy(ctx, "admin@example.com", repositories.LoginPolicyTOTP, recovery[0], ip))
+	assert.Equal(t, repositories.LoginPolicyTOTP, admins.admins["admin@example.com"].LoginPolicy)
+
+	recovery, err = s.ConfirmEnrollment(ctx, "admin@example.com", codeFor(second), recovery[1], ip)
+	require.NoError(t, err)
+	assert.NotEqual(t, enabled, admins.admins["admin@example.com"].TOTPSecret)
+
+	require.NoError(t, s.Disable(ctx, "admin@example.com", recovery[0], ip))
+	assert.False(t, admins.admins["admin@example.com"].TOTPEnabled())
+}
+
+func TestTOTPConfirmRejectsRestartedEnrollment(t *testing.T) {
+	ctx := context.Background()
+	admins := &memoryAdminRepository{admins: map[string]repositories.Admin{
+		"admin@example.com": {Email: "admin@example.com", Role: repositories.AdminRoleOwner},
+	}}
+	s := NewTOTPService(admins, newTestVerificationService(newMemoryVerificationRepository()), &config.Config{JWTSecret: "test-secret"})
+
+	_, err := s.BeginEnrollment(ctx, "admin@example.com")
+	require.NoError(t, err)
+	checked := admins.admins["admin@example.com"].TOTPPendingSecret
+	_, err = s.BeginEnrollment(ctx, "admin@example.com")
+	require.NoError(t, err)
+
+	// The secret a code was checked against must be the one enabled, not
+	// whatever a concurrent enrollment left pending.
+	err = admins.EnableTOTP(ctx, "admin@example.com", checked, 0, nil, repositories.LoginPolicyEither)
+	assert.ErrorIs(t, err, repositories.ErrTOTPEnrollmentChanged)
+	assert.False(t, admins.admins["admin@example.com"].TOTPEnabled())
+}
+
+func TestTOTPCurrentCodeGuessesLockOut(t *testing.T) {
+	ctx := context.Background()
+	admins := &memoryAdminRepository{admins: map[string]repositories.Admin{
+		"admin@example.com": {Email: "admin@example.com", Role: repositories.AdminRoleOwner},
+	}}
+	verification := newMemoryVerificationRepository()
+	s := NewTOTPService(admins, newTestVerificationService(verification), &config.Config{JWTSecret: "test-secret"})
+
+	enrollment, err := s.BeginEnrollment(ctx, "admin@example.com")
+	require.NoError(t, err)
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	recovery, err := s.ConfirmEnrollment(ctx, "admin@example.com", hotp(sha1.New, secret, uint64(totpStep(time.Now())), totpDigits), "", "203.0.113.1")
+	require.NoError(t, err)
+
+	// Guessing the current code is limited like signing in, and counted
+	// towards the same lockout.
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, s.Disable(ctx, "admin@example.com", "zzzz-zzzz", "203.0.113.1"), ErrInvalidVerificationCode)
+	}
+	assert.ErrorIs(t, s.SetPolicy(ctx, "admin@example.com", repositories.LoginPolicyEmail, "zzzz-zzzz", "203.0.113.1"), ErrVerificationLocked)
+	assert.ErrorIs(t, s.Disable(ctx, "admin@example.com", recovery[0], "203.0.113.1"), ErrVerificationLocked)
+	assert.True(t, admins.admins["admin@example.com"].TOTPEnabled())
+	assert.Len(t, verification.failures, 3)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTOTPRFC6238Vectors checks the generator against the test vectors in
+// RFC 6238 appendix B.
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	hashes := map[string]func() hash.Hash{
+		"SHA1":   sha1.New,
+		"SHA256": sha256.New,
+		"SHA512": sha512.New,
+	}
+	vectors := []struct {
+		unix int64
+		mode string
+		code string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+
+	for _, v := range vectors {
+		step := totpStep(time.Unix(v.unix, 0))
+		assert.Equal(t, v.code, hotp(hashes[v.mode], secrets[v.mode], uint64(step), 8), "%s at %d", v.mode, v.unix)
+	}
+}
+
+func TestMatchTOTPAllowsClockSkew(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111109, 0)
+	code := hotp(sha1.New, secret, uint64(totpStep(now.Add(-totpPeriod))), totpDigits)
+
+	step, ok := matchTOTP(secret, code, now)
+	require.True(t, ok)
+	assert.Equal(t, totpStep(now)-1, step)
+
+	_, ok = matchTOTP(secret, code, now.Add(2*totpPeriod))
+	assert.False(t, ok)
+}
+
+func TestSealSecret(t *testing.T) {
+	key := deriveKey("test-secret", "totp")
+	sealed, err := sealSecret(key, []byte("12345678901234567890"))
+	require.NoError(t, err)
+
+	opened, err := openSecret(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("12345678901234567890"), opened)
+
+	_, err = openSecret(deriveKey("other-secret", "totp"), sealed)
+	assert.Error(t, err)
+}
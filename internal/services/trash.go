@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+)
+
+// trashPurgeBatchSize caps the contacts removed together in one purge.
+const trashPurgeBatchSize = 100
+
+func (s *ContactService) RestoreContact(ctx context.Context, id string) (repositories.Contact, error) {
+	return s.repository.RestoreContact(ctx, id)
+}
+
+// TrashService permanently removes contacts that have been in the trash for
+// too long, along with what is stored about them elsewhere.
+type TrashService struct {
+	contacts repositories.ContactRepository
+	people   repositories.PersonRepository
+	notes    repositories.NoteRepository
+}
+
+func NewTrashService(contacts repositories.ContactRepository, people repositories.PersonRepository, notes repositories.NoteRepository) *TrashService {
+	return &TrashService{
+		contacts: contacts,
+		people:   people,
+		notes:    notes,
+	}
+}
+
+// PurgeTrash permanently removes contacts that have been in the trash for
+// longer than retention with their notes, and stops counting them on their
+// people.
+func (s *TrashService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	trashedBefore := time.Now().Add(-retention)
+
+	var purged int64
+	for {
+		contacts, err := s.contacts.GetContactsTrashedBefore(ctx, trashedBefore, trashPurgeBatchSize)
+		if err != nil || len(contacts) == 0 {
+			return purged, err
+		}
+		n, err := s.purge(ctx, contacts, trashedBefore)
+		purged += n
+		if err != nil || len(contacts) < trashPurgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+// purge removes one batch of trashed contacts. Notes and submission counts
+// are only touched for the contacts actually removed, so a contact restored
+// since the batch was read keeps its notes.
+func (s *TrashService) purge(ctx context.Context, contacts []repositories.Contact, trashedBefore time.Time) (int64, error) {
+	ids := make([]primitive.ObjectID, len(contacts))
+	for i, contact := range contacts {
+		ids[i] = contact.ID
+	}
+
+	purged, err := s.contacts.DeleteTrashedContacts(ctx, ids, trashedBefore, func(ctx context.Context, ids []primitive.ObjectID) error {
+		_, err := s.notes.DeleteNotesByContactIDs(ctx, ids)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	submissions := map[primitive.ObjectID]int{}
+	for _, contact := range purged {
+		if contact.PersonID != nil {
+			submissions[*contact.PersonID]++
+		}
+	}
+	for personID, n := range submissions {
+		if err := s.people.RemoveSubmissions(ctx, personID, n); err != nil {
+			log.Printf("Failed to update person %s: %v", personID.Hex(), err)
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+// RunTrashPurger purges the trash every interval until ctx is cancelled. A
+// retention of zero or less keeps trashed contacts forever.
+func (s *TrashService) RunTrashPurger(ctx context.Context, retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeTrash(ctx, retention)
+		if err != nil {
+			log.Printf("Failed to purge trashed contacts: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d trashed contacts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryContactRepository) GetContactsTrashedBefore(_ context.Context, trashedBefore time.Time, limit int) ([]repositories.Contact, error) {
+	var trashed []repositories.Contact
+	for _, contact := range r.contacts {
+		if contact.DeletedAt != nil && contact.DeletedAt.Before(trashedBefore) && len(trashed) < limit {
+			trashed = append(trashed, *contact)
+		}
+	}
+	return trashed, nil
+}
+
+func (r *memoryContactRepository) DeleteTrashedContacts(ctx context.Context, ids []primitive.ObjectID, trashedBefore time.Time, removeRelated func(ctx context.Context, ids []primitive.ObjectID) error) ([]repositories.Contact, error) {
+	var kept []*repositories.Contact
+	var deleted []repositories.Contact
+	var deletedIDs []primitive.ObjectID
+	for _, contact := range r.contacts {
+		if slices.Contains(ids, contact.ID) && contact.DeletedAt != nil && contact.DeletedAt.Before(trashedBefore) {
+			deleted = append(deleted, *contact)
+			deletedIDs = append(deletedIDs, contact.ID)
+		} else {
+			kept = append(kept, contact)
+		}
+	}
+	if len(deleted) == 0 {
+		return deleted, nil
+	}
+	if err := removeRelated(ctx, deletedIDs); err != nil {
+		return nil, err
+	}
+	r.contacts = kept
+	return deleted, nil
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+	s := NewContactService(contacts, people)
+
+	for _, email := range []string{"alex@example.com", "alex@example.com", "sam@example.com"} {
+		require.NoError(t, s.CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: email}))
+	}
+	alex := *contacts.contacts[0].PersonID
+	sam := *contacts.contacts[2].PersonID
+
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+	recently := time.Now().Add(-time.Hour)
+	contacts.contacts[0].DeletedAt = &longAgo
+	contacts.contacts[2].DeletedAt = &recently
+	kept := []primitive.ObjectID{contacts.contacts[1].ID, contacts.contacts[2].ID}
+	notes := &memoryNoteRepository{notes: []repositories.Note{
+		{ID: primitive.NewObjectID(), ContactID: contacts.contacts[0].ID},
+		{ID: primitive.NewObjectID(), ContactID: contacts.contacts[1].ID},
+	}}
+
+	purged, err := NewTrashService(contacts, people, notes).PurgeTrash(ctx, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var remaining []primitive.ObjectID
+	for _, contact := range contacts.contacts {
+		remaining = append(remaining, contact.ID)
+	}
+	assert.Equal(t, kept, remaining)
+	require.Len(t, notes.notes, 1)
+	assert.Equal(t, kept[0], notes.notes[0].ContactID)
+	assert.Equal(t, 1, people.people[alex].MessageCount)
+	assert.Equal(t, 1, people.people[sam].MessageCount)
+}
+
+func TestPurgeTrashInBatches(t *testing.T) {
+	ctx := context.Background()
+	contacts := &memoryContactRepository{}
+	trashedAt := time.Now().Add(-48 * time.Hour)
+	for i := 0; i < trashPurgeBatchSize*2+1; i++ {
+		contacts.contacts = append(contacts.contacts, &repositories.Contact{ID: primitive.NewObjectID(), DeletedAt: &trashedAt})
+	}
+
+	purged, err := NewTrashService(contacts, newMemoryPersonRepository(), &memoryNoteRepository{}).PurgeTrash(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(trashPurgeBatchSize*2+1), purged)
+	assert.Empty(t, contacts.contacts)
+}
+
+func TestPurgeTrashKeepsRestoredContacts(t *testing.T) {
+	ctx := context.Background()
+	people := newMemoryPersonRepository()
+	contacts := &memoryContactRepository{}
+	s := NewContactService(contacts, people)
+
+	require.NoError(t, s.CreateContact(ctx, &repositories.Contact{Name: "Alex", Email: "alex@example.com"}))
+	alex := *contacts.contacts[0].PersonID
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+	contacts.contacts[0].DeletedAt = &longAgo
+	notes := &memoryNoteRepository{notes: []repositories.Note{
+		{ID: primitive.NewObjectID(), ContactID: contacts.contacts[0].ID},
+	}}
+
+	// The contact is restored after the purge reads the batch.
+	batch, err := contacts.GetContactsTrashedBefore(ctx, time.Now(), trashPurgeBatchSize)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	contacts.contacts[0].DeletedAt = nil
+
+	purged, err := NewTrashService(contacts, people, notes).purge(ctx, batch, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Len(t, contacts.contacts, 1)
+	assert.Len(t, notes.notes, 1)
+	assert.Equal(t, 1, people.people[alex].MessageCount)
+}
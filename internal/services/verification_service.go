@@ -2,18 +2,44 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"time"
 
 	"chanterelle/internal/config"
-	"chanterelle/internal/models"
 	"chanterelle/internal/repositories"
 )
 
+// ipFailureWindow is how far back failures from an IP are counted.
+const ipFailureWindow = time.Hour
+
+var (
+	ErrInvalidVerificationCode  = errors.New("invalid verification code")
+	ErrVerificationCodeNotFound = errors.New("invalid or expired verification code")
+	ErrVerificationLocked       = errors.New("too many failed attempts")
+)
+
+// VerificationLockedError is returned while an email or IP is locked out
+// for failing to verify too often. It matches ErrVerificationLocked.
+type VerificationLockedError struct {
+	Until time.Time
+}
+
+func (e *VerificationLockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again after %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *VerificationLockedError) Unwrap() error {
+	return ErrVerificationLocked
+}
+
 type VerificationService struct {
 	cfg        *config.Config
 	repository repositories.VerificationRepository
@@ -36,43 +62,140 @@ func (s *VerificationService) CreateVerificationCode(ctx context.Context, email
 	num := int(binary.BigEndian.Uint32(bytes))%900000 + 100000 // Range: 100000-999999
 	code := strconv.Itoa(num)
 
-	// Create verification code
-	verificationCode := &models.VerificationCode{
-		Email:     email,
-		Code:      code,
-		ExpiresAt: time.Now().Add(s.cfg.VerificationCodeExpiry),
-	}
-
-	if err := s.repository.CreateVerificationCode(ctx, verificationCode.Email, verificationCode.Code, s.cfg.VerificationCodeExpiry); err != nil {
+	if err := s.repository.CreateVerificationCode(ctx, email, s.hashCode(email, code), s.cfg.VerificationCodeExpiry); err != nil {
 		return "", err
 	}
 
 	return code, nil
 }
 
-func (s *VerificationService) GetCodeByEmail(ctx context.Context, email string) (*models.VerificationCode, error) {
-	log.Println("Getting verification code for email:", email)
-	verificationCode, err := s.repository.GetCodeByEmail(ctx, email)
+// hashCode is the keyed hash stored in place of a code. Mixing in the email
+// stops a hash being reused for another address.
+func (s *VerificationService) hashCode(email, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.JWTSecret))
+	mac.Write([]byte(email))
+	mac.Write([]byte{0})
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCode checks a code entered for email from ip and consumes it when
+// it matches. Each guess uses up one of the code's attempts and wrong ones
+// are recorded. Too many wrong guesses at one code, or at any codes sent
+// to email within a code lifetime, invalidate the code and lock the email
+// out for exponentially longer each time, so asking for a new code doesn't
+// buy more guesses.
+func (s *VerificationService) VerifyCode(ctx context.Context, email, code, ip string) error {
+	now := time.Now()
+	if err := s.checkLockouts(ctx, email, ip, now); err != nil {
+		return err
+	}
+
+	stored, err := s.repository.ConsumeCode(ctx, email, s.hashCode(email, code), now, s.cfg.VerificationMaxAttempts)
+	if err == nil {
+		return s.repository.ClearLockout(ctx, email)
+	}
+	if errors.Is(err, repositories.ErrVerificationCodeNotFound) {
+		if err := s.RecordFailure(ctx, email, ip, "no valid code"); err != nil {
+			return err
+		}
+		return ErrVerificationCodeNotFound
+	}
+	if !errors.Is(err, repositories.ErrVerificationCodeMismatch) {
+		return err
+	}
+
+	if err := s.RecordFailure(ctx, email, ip, "wrong code"); err != nil {
+		return err
+	}
+	failures, err := s.repository.CountFailuresByEmail(ctx, email, now.Add(-s.cfg.VerificationCodeExpiry))
+	if err != nil {
+		return err
+	}
+	if stored.Attempts >= s.cfg.VerificationMaxAttempts || failures >= int64(s.cfg.VerificationMaxAttempts) {
+		if err := s.repository.DeleteCodeByID(ctx, stored.ID); err != nil {
+			return err
+		}
+		lockout, err := s.repository.Lockout(ctx, email, now, s.cfg.VerificationLockout, s.cfg.VerificationMaxLockout)
+		if err != nil {
+			return err
+		}
+		log.Printf("Locked out %s until %s after %d failed verification attempts", email, lockout.LockedUntil.Format(time.RFC3339), max(int64(stored.Attempts), failures))
+		return &VerificationLockedError{Until: lockout.LockedUntil}
+	}
+	return ErrInvalidVerificationCode
+}
+
+// VerifyAuthenticatorCode runs check, which verifies an authenticator or
+// recovery code for email, under the same lockouts as emailed codes. There
+// is no code to invalidate, so VerificationMaxAttempts failures within the
+// code lifetime lock the email out instead.
+func (s *VerificationService) VerifyAuthenticatorCode(ctx context.Context, email, ip string, check func(ctx context.Context) error) error {
+	now := time.Now()
+	if err := s.checkLockouts(ctx, email, ip, now); err != nil {
+		return err
+	}
+
+	err := check(ctx)
+	if err == nil {
+		return s.repository.ClearLockout(ctx, email)
+	}
+	if !errors.Is(err, ErrInvalidTOTPCode) {
+		return err
+	}
+
+	if err := s.RecordFailure(ctx, email, ip, "wrong authenticator code"); err != nil {
+		return err
+	}
+	failures, err := s.repository.CountFailuresByEmail(ctx, email, now.Add(-s.cfg.VerificationCodeExpiry))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if failures >= int64(s.cfg.VerificationMaxAttempts) {
+		lockout, err := s.repository.Lockout(ctx, email, now, s.cfg.VerificationLockout, s.cfg.VerificationMaxLockout)
+		if err != nil {
+			return err
+		}
+		log.Printf("Locked out %s until %s after %d wrong authenticator codes", email, lockout.LockedUntil.Format(time.RFC3339), failures)
+		return &VerificationLockedError{Until: lockout.LockedUntil}
 	}
-	log.Println("Verification code for email:", email, "is", verificationCode.Code, "expires at", verificationCode.ExpiresAt.Format("2006-01-02 15:04:05"))
+	return ErrInvalidVerificationCode
+}
 
-	// Check if code is expired
-	if verificationCode.ExpiresAt.Before(time.Now()) {
-		return nil, fmt.Errorf("verification code has expired")
+// checkLockouts refuses emails that are locked out and IPs that have
+// failed too often recently.
+func (s *VerificationService) checkLockouts(ctx context.Context, email, ip string, now time.Time) error {
+	lockout, err := s.repository.GetLockout(ctx, email)
+	if err != nil {
+		return err
 	}
+	if lockout != nil && lockout.LockedUntil.After(now) {
+		return &VerificationLockedError{Until: lockout.LockedUntil}
+	}
+
+	if s.cfg.VerificationMaxIPFailures > 0 {
+		failures, err := s.repository.CountFailuresByIP(ctx, ip, now.Add(-ipFailureWindow))
+		if err != nil {
+			return err
+		}
+		if failures >= int64(s.cfg.VerificationMaxIPFailures) {
+			return &VerificationLockedError{Until: now.Add(ipFailureWindow)}
+		}
+	}
+	return nil
+}
 
-	// Convert repository type to model type
-	return &models.VerificationCode{
-		Email:     verificationCode.Email,
-		Code:      verificationCode.Code,
-		ExpiresAt: verificationCode.ExpiresAt,
-	}, nil
+// RecordFailure logs a failed sign-in attempt for the audit view.
+func (s *VerificationService) RecordFailure(ctx context.Context, email, ip, reason string) error {
+	return s.repository.RecordFailure(ctx, &repositories.VerificationFailure{
+		Email:  email,
+		IP:     ip,
+		Reason: reason,
+	})
 }
 
-func (s *VerificationService) DeleteCodeByEmail(ctx context.Context, email string) error {
-	return s.repository.DeleteCodeByEmail(ctx, email)
+func (s *VerificationService) GetFailures(ctx context.Context, filter repositories.VerificationFailureFilter) ([]repositories.VerificationFailure, error) {
+	return s.repository.GetFailures(ctx, filter)
 }
 
 func (s *VerificationService) DeleteExpiredCodes(ctx context.Context) error {
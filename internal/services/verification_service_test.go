@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"chanterelle/internal/config"
+	"chanterelle/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryVerificationRepository is an in-memory VerificationRepository for
+// tests. It is safe for concurrent use.
+type memoryVerificationRepository struct {
+	repositories.VerificationRepository
+	mu       sync.Mutex
+	codes    []repositories.VerificationCode
+	failures []repositories.VerificationFailure
+	lockouts map[string]*repositories.VerificationLockout
+}
+
+func newMemoryVerificationRepository() *memoryVerificationRepository {
+	return &memoryVerificationRepository{lockouts: map[string]*repositories.VerificationLockout{}}
+}
+
+func (r *memoryVerificationRepository) CreateVerificationCode(_ context.Context, email, codeHash string, expiry time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var kept []repositories.VerificationCode
+	for _, code := range r.codes {
+		if code.Email != email {
+			kept = append(kept, code)
+		}
+	}
+	r.codes = append(kept, repositories.VerificationCode{
+		ID:        time.Now().Format(time.RFC3339Nano),
+		Email:     email,
+		CodeHash:  codeHash,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(expiry),
+	})
+	return nil
+}
+
+func (r *memoryVerificationRepository) ConsumeCode(_ context.Context, email, codeHash string, now time.Time, maxAttempts int) (*repositories.VerificationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		code := &r.codes[i]
+		if code.Email != email || !code.ExpiresAt.After(now) || code.Attempts >= maxAttempts {
+			continue
+		}
+		code.Attempts++
+		consumed := *code
+		if consumed.CodeHash != codeHash {
+			return &consumed, repositories.ErrVerificationCodeMismatch
+		}
+		r.codes = append(r.codes[:i], r.codes[i+1:]...)
+		return &consumed, nil
+	}
+	return nil, repositories.ErrVerificationCodeNotFound
+}
+
+func (r *memoryVerificationRepository) DeleteCodeByID(_ context.Context, codeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, code := range r.codes {
+		if code.ID == codeID {
+			r.codes = append(r.codes[:i], r.codes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryVerificationRepository) RecordFailure(_ context.Context, failure *repositories.VerificationFailure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failure.CreatedAt = time.Now()
+	r.failures = append(r.failures, *failure)
+	return nil
+}
+
+func (r *memoryVerificationRepository) CountFailuresByIP(_ context.Context, ip string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, failure := range r.failures {
+		if failure.IP == ip && !failure.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryVerificationRepository) CountFailuresByEmail(_ context.Context, email string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, failure := range r.failures {
+		if failure.Email == email && !failure.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryVerificationRepository) GetLockout(_ context.Context, email string) (*repositories.VerificationLockout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lockouts[email], nil
+}
+
+func (r *memoryVerificationRepository) Lockout(_ context.Context, email string, now time.Time, base, max time.Duration) (repositories.VerificationLockout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lockout := r.lockouts[email]
+	if lockout == nil {
+		lockout = &repositories.VerificationLockout{Email: email}
+		r.lockouts[email] = lockout
+	}
+	lockout.Level++
+	duration := base << (lockout.Level - 1)
+	if duration > max {
+		duration = max
+	}
+	lockout.LockedUntil = now.Add(duration)
+	return *lockout, nil
+}
+
+func (r *memoryVerificationRepository) ClearLockout(_ context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lockouts, email)
+	return nil
+}
+
+func newTestVerificationService(repo repositories.VerificationRepository) *VerificationService {
+	return NewVerificationService(&config.Config{
+		JWTSecret:                 "test-secret",
+		VerificationCodeExpiry:    15 * time.Minute,
+		VerificationMaxAttempts:   3,
+		VerificationLockout:       time.Minute,
+		VerificationMaxLockout:    time.Hour,
+		VerificationMaxIPFailures: 100,
+	}, repo)
+}
+
+func TestVerifyCodeLocksOutAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryVerificationRepository()
+	s := newTestVerificationService(repo)
+
+	code, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", wrong, "203.0.113.1"), ErrInvalidVerificationCode)
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", wrong, "203.0.113.1"), ErrInvalidVerificationCode)
+
+	err = s.VerifyCode(ctx, "admin@example.com", wrong, "203.0.113.1")
+	var locked *VerificationLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), locked.Until, 5*time.Second)
+
+	// The right code no longer works: it was invalidated and the email is
+	// locked out.
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", code, "203.0.113.1"), ErrVerificationLocked)
+	assert.Len(t, repo.failures, 3)
+
+	// The next lockout lasts twice as long.
+	repo.lockouts["admin@example.com"].LockedUntil = time.Now().Add(-time.Second)
+	_, err = s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = s.VerifyCode(ctx, "admin@example.com", wrong, "203.0.113.1")
+	}
+	require.ErrorAs(t, err, &locked)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), locked.Until, 5*time.Second)
+}
+
+func TestVerifyCodeLockoutSurvivesNewCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryVerificationRepository()
+	s := newTestVerificationService(repo)
+	wrongFor := func(code string) string {
+		if code == "000000" {
+			return "111111"
+		}
+		return "000000"
+	}
+
+	// Stopping one guess short and asking for a new code doesn't reset the
+	// count.
+	code, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", wrongFor(code), "203.0.113.1"), ErrInvalidVerificationCode)
+	}
+	code, err = s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", wrongFor(code), "203.0.113.1"), ErrVerificationLocked)
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", code, "203.0.113.1"), ErrVerificationLocked)
+	assert.Empty(t, repo.codes)
+}
+
+func TestVerifyCodeSucceedsAndClearsLockout(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryVerificationRepository()
+	s := newTestVerificationService(repo)
+	repo.lockouts["admin@example.com"] = &repositories.VerificationLockout{
+		Email:       "admin@example.com",
+		Level:       2,
+		LockedUntil: time.Now().Add(-time.Minute),
+	}
+
+	code, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	require.NoError(t, s.VerifyCode(ctx, "admin@example.com", code, "203.0.113.1"))
+	assert.Empty(t, repo.lockouts)
+
+	// Codes can only be used once.
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", code, "203.0.113.1"), ErrVerificationCodeNotFound)
+}
+
+func TestVerificationCodesAreHashedAndReplaced(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryVerificationRepository()
+	s := newTestVerificationService(repo)
+
+	first, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+	second, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+
+	require.Len(t, repo.codes, 1)
+	assert.NotContains(t, repo.codes[0].CodeHash, second)
+	if first != second {
+		assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", first, "203.0.113.1"), ErrInvalidVerificationCode)
+	}
+	assert.NoError(t, s.VerifyCode(ctx, "admin@example.com", second, "203.0.113.1"))
+}
+
+func TestVerifyCodeConcurrentGuessesShareTheLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryVerificationRepository()
+	s := newTestVerificationService(repo)
+
+	code, err := s.CreateVerificationCode(ctx, "admin@example.com")
+	require.NoError(t, err)
+
+	const guesses = 50
+	results := make(chan error, guesses)
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			guess := strconv.Itoa(100000 + i)
+			if guess == code {
+				guess = "000000"
+			}
+			results <- s.VerifyCode(ctx, "admin@example.com", guess, "203.0.113.1")
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	var invalid, locked int
+	for err := range results {
+		switch {
+		case errors.Is(err, ErrInvalidVerificationCode):
+			invalid++
+		case errors.Is(err, ErrVerificationLocked):
+			locked++
+		default:
+			assert.ErrorIs(t, err, ErrVerificationCodeNotFound)
+		}
+	}
+	// Only VerificationMaxAttempts guesses were checked against the code:
+	// the last of them locked the email out.
+	assert.Equal(t, 2, invalid)
+	assert.GreaterOrEqual(t, locked, 1)
+	wrongCodes := 0
+	for _, failure := range repo.failures {
+		if failure.Reason == "wrong code" {
+			wrongCodes++
+		}
+	}
+	assert.Equal(t, 3, wrongCodes)
+
+	repo.lockouts["admin@example.com"].LockedUntil = time.Now().Add(-time.Second)
+	assert.ErrorIs(t, s.VerifyCode(ctx, "admin@example.com", code, "203.0.113.1"), ErrVerificationCodeNotFound)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"chanterelle/internal/handlers"
+	"chanterelle/internal/ratelimit"
 	"chanterelle/internal/repositories"
 	"chanterelle/internal/services"
 	"context"
@@ -49,21 +50,167 @@ func main() {
 
 	// Initialize repositories with MongoDB
 	contactRepo := repositories.NewMongoContactRepository(db)
+	if err := contactRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create contact indexes: %v", err)
+	}
+	personRepo := repositories.NewMongoPersonRepository(db)
+	if err := personRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create people indexes: %v", err)
+	}
+	noteRepo := repositories.NewMongoNoteRepository(db)
+	if err := noteRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create note indexes: %v", err)
+	}
+	bookingRepo := repositories.NewMongoBookingRepository(db)
+	if err := bookingRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create booking indexes: %v", err)
+	}
+	routingRuleRepo := repositories.NewMongoRoutingRuleRepository(db)
+	consentRepo := repositories.NewMongoConsentRepository(db)
+	if err := consentRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create consent indexes: %v", err)
+	}
+	subscriptionRepo := repositories.NewMongoSubscriptionRepository(db)
+	if err := subscriptionRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create subscription indexes: %v", err)
+	}
+	autoReplyRepo := repositories.NewMongoAutoReplyRepository(db)
+	if err := autoReplyRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create auto-reply indexes: %v", err)
+	}
 	verificationRepo := repositories.NewMongoVerificationRepository(db)
-	contactService := services.NewContactService(contactRepo)
+	if err := verificationRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create verification indexes: %v", err)
+	}
+	formTokenRepo := repositories.NewMongoFormTokenRepository(db)
+	if err := formTokenRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create form token indexes: %v", err)
+	}
+	adminRepo := repositories.NewMongoAdminRepository(db)
+	sessionRepo := repositories.NewMongoSessionRepository(db)
+	if err := sessionRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create session indexes: %v", err)
+	}
+	contactService := services.NewContactService(contactRepo, personRepo)
+	trashService := services.NewTrashService(contactRepo, personRepo, noteRepo)
 	notificationService := services.NewNotificationService(cfg)
 	verificationService := services.NewVerificationService(cfg, verificationRepo)
+	noteService := services.NewNoteService(noteRepo, contactRepo)
+	spamService := services.NewSpamService(formTokenRepo, cfg)
+	bookingService := services.NewBookingService(bookingRepo, notificationService, spamService)
+	mailchimpSync := services.NewMailchimpSyncService(contactRepo, notificationService, cfg)
+	consentService := services.NewConsentService(consentRepo, personRepo, cfg)
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo, mailchimpSync, notificationService, consentService, cfg)
+	routingService := services.NewRoutingService(routingRuleRepo, notificationService, subscriptionService, cfg)
+	autoReplyService := services.NewAutoReplyService(autoReplyRepo, routingService, notificationService, cfg)
+	adminService := services.NewAdminService(adminRepo, sessionRepo, notificationService, cfg)
+	sessionService := services.NewSessionService(sessionRepo, adminRepo, cfg)
+	totpService := services.NewTOTPService(adminRepo, verificationService, cfg)
+	if err := adminService.SeedOwner(ctx); err != nil {
+		log.Fatalf("Failed to seed the owner admin: %v", err)
+	}
+	privacyService := services.NewPrivacyService(services.PrivacyRepositories{
+		Contacts:      contactRepo,
+		People:        personRepo,
+		Notes:         noteRepo,
+		Bookings:      bookingRepo,
+		Consents:      consentRepo,
+		Subscriptions: subscriptionRepo,
+		Verification:  verificationRepo,
+		AutoReplies:   autoReplyRepo,
+		Sessions:      sessionRepo,
+	}, notificationService, cfg)
+
+	// Link contacts stored before people existed
+	go func() {
+		if _, err := contactService.LinkUnassignedContacts(context.Background()); err != nil {
+			log.Printf("Failed to link contacts to people: %v", err)
+		}
+	}()
+
+	// Background workers run until the server exits
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Permanently remove contacts that have been in the trash too long
+	go trashService.RunTrashPurger(workerCtx, cfg.TrashRetention, time.Hour)
+
+	// Subscribe queued contacts to Mailchimp, retrying failures with backoff
+	go mailchimpSync.RunMailchimpSyncWorker(workerCtx, 30*time.Second)
+
+	// Rate limits for the public endpoints
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "mongo":
+		mongoStore := ratelimit.NewMongoStore(db)
+		if err := mongoStore.EnsureIndexes(ctx); err != nil {
+			log.Fatalf("Failed to create rate limit indexes: %v", err)
+		}
+		rateLimitStore = mongoStore
+	default:
+		log.Fatalf("Unknown RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+	}
+	limiter := ratelimit.NewLimiter(rateLimitStore)
+	contactLimit := ratelimit.Rule{
+		Name:     "contacts",
+		PerIP:    mustParseLimit("RATE_LIMIT_CONTACT_IP", cfg.RateLimitContactIP),
+		PerEmail: mustParseLimit("RATE_LIMIT_CONTACT_EMAIL", cfg.RateLimitContactEmail),
+	}
+	bookingLimit := ratelimit.Rule{
+		Name:     "bookings",
+		PerIP:    contactLimit.PerIP,
+		PerEmail: contactLimit.PerEmail,
+	}
+	verificationLimit := ratelimit.Rule{
+		Name:     "verification",
+		PerIP:    mustParseLimit("RATE_LIMIT_VERIFICATION_IP", cfg.RateLimitVerificationIP),
+		PerEmail: mustParseLimit("RATE_LIMIT_VERIFICATION_EMAIL", cfg.RateLimitVerificationEmail),
+	}
+	verifyCodeLimit := ratelimit.Rule{
+		Name:     "verify-code",
+		PerIP:    verificationLimit.PerIP,
+		PerEmail: verificationLimit.PerEmail,
+	}
+	privacyLimit := ratelimit.Rule{
+		Name:     "privacy",
+		PerIP:    verificationLimit.PerIP,
+		PerEmail: verificationLimit.PerEmail,
+	}
 
 	// Initialize handlers
-	handlers := handlers.NewHandlers(contactService, notificationService, verificationService, cfg)
+	handlers := handlers.NewHandlers(handlers.Services{
+		Contacts:      contactService,
+		Notifications: notificationService,
+		Verification:  verificationService,
+		Notes:         noteService,
+		Bookings:      bookingService,
+		Routing:       routingService,
+		Spam:          spamService,
+		AutoReplies:   autoReplyService,
+		MailchimpSync: mailchimpSync,
+		Subscriptions: subscriptionService,
+		Consents:      consentService,
+		Privacy:       privacyService,
+		Admins:        adminService,
+		Sessions:      sessionService,
+		TOTP:          totpService,
+	}, cfg)
 
 	// Set up router
 	router := gin.Default()
+	// Only believe forwarded client IPs from known proxies, so clients can't
+	// pick their own IP to dodge rate limits or lock others out
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	router.TrustedPlatform = cfg.TrustedPlatform
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "ETag", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -79,18 +226,91 @@ func main() {
 	// Public routes
 	r := router.Group("/api")
 	// Contact creation (public)
-	r.POST("/contacts", handlers.CreateContact)
+	r.POST("/contacts", limiter.Middleware(contactLimit), handlers.CreateContact)
+	r.GET("/contact-form-token", handlers.GetContactFormToken)
+	// Booking inquiries (public)
+	r.POST("/bookings", limiter.Middleware(bookingLimit), handlers.CreateBooking)
+	// Double opt-in confirmation (public)
+	r.POST("/subscriptions/confirm", handlers.ConfirmSubscription)
+	// Self-service data export and erasure (public, by emailed link)
+	r.POST("/privacy/requests", limiter.Middleware(privacyLimit), handlers.RequestPrivacyLink)
+	r.POST("/privacy/requests/export", handlers.ExportOwnData)
+	r.POST("/privacy/requests/erase", handlers.EraseOwnData)
 	// Authentication endpoints
-	r.POST("/send-verification", handlers.SendVerification)
-	r.POST("/verify-code", handlers.VerifyCode)
+	r.POST("/send-verification", limiter.Middleware(verificationLimit), handlers.SendVerification)
+	r.POST("/verify-code", limiter.Middleware(verifyCodeLimit), handlers.VerifyCode)
+	r.POST("/auth/refresh", handlers.RefreshSession)
 
 	// Protected routes
 	authGroup := r.Group("")
 	authGroup.Use(handlers.JWTAuth())
 
+	// Each protected route names the least role allowed to use it
+	viewer := handlers.RequireRole(repositories.AdminRoleViewer)
+	editor := handlers.RequireRole(repositories.AdminRoleEditor)
+	owner := handlers.RequireRole(repositories.AdminRoleOwner)
+
+	// Sessions of the signed-in admin
+	authGroup.POST("/auth/logout", viewer, handlers.Logout)
+	authGroup.POST("/auth/logout-all", viewer, handlers.LogoutAll)
+	authGroup.GET("/auth/sessions", viewer, handlers.GetSessions)
+	authGroup.DELETE("/auth/sessions/:id", viewer, handlers.RevokeSession)
+
+	// Authenticator app second factor of the signed-in admin
+	authGroup.POST("/auth/totp/enroll", viewer, handlers.EnrollTOTP)
+	authGroup.POST("/auth/totp/confirm", viewer, handlers.ConfirmTOTP)
+	authGroup.DELETE("/auth/totp", viewer, handlers.DisableTOTP)
+	authGroup.PUT("/auth/login-policy", viewer, handlers.UpdateLoginPolicy)
+
 	// Get all contacts
-	authGroup.GET("/contacts", handlers.GetContacts)
-	authGroup.DELETE("/contacts/:id", handlers.DeleteContact)
+	authGroup.GET("/contacts", viewer, handlers.GetContacts)
+	authGroup.GET("/contacts/search", viewer, handlers.SearchContacts)
+	authGroup.GET("/contacts/export", viewer, handlers.ExportContacts)
+	authGroup.POST("/contacts/import", editor, handlers.ImportContacts)
+	authGroup.GET("/contacts/status-counts", viewer, handlers.GetContactStatusCounts)
+	authGroup.GET("/contacts/trash", viewer, handlers.GetTrash)
+	authGroup.GET("/contacts/:id", viewer, handlers.GetContactByID)
+	authGroup.PATCH("/contacts/:id", editor, handlers.UpdateContact)
+	authGroup.PUT("/contacts/:id/status", editor, handlers.ChangeContactStatus)
+	authGroup.DELETE("/contacts/:id", editor, handlers.DeleteContact)
+	authGroup.POST("/contacts/:id/restore", editor, handlers.RestoreContact)
+	authGroup.POST("/contacts/:id/reply", editor, handlers.ReplyToContact)
+
+	// Tags and internal notes
+	authGroup.GET("/tags", viewer, handlers.GetTags)
+	authGroup.POST("/contacts/:id/tags", editor, handlers.AddContactTags)
+	authGroup.DELETE("/contacts/:id/tags/:tag", editor, handlers.RemoveContactTag)
+	authGroup.GET("/contacts/:id/notes", viewer, handlers.GetContactNotes)
+	authGroup.POST("/contacts/:id/notes", editor, handlers.AddContactNote)
+
+	// People and their message timelines
+	authGroup.GET("/people", viewer, handlers.GetPeople)
+	authGroup.GET("/people/:id", viewer, handlers.GetPerson)
+	authGroup.GET("/people/:id/timeline", viewer, handlers.GetPersonTimeline)
+	authGroup.POST("/people/:id/merge", editor, handlers.MergePeople)
+	authGroup.GET("/people/:id/consents", viewer, handlers.ExportPersonConsents)
+
+	// Data subject access and erasure requests
+	authGroup.GET("/privacy/export", owner, handlers.ExportPersonalData)
+	authGroup.POST("/privacy/erase", owner, handlers.ErasePersonalData)
+
+	// Per-category routing of contact form submissions
+	authGroup.GET("/routing-rules", viewer, handlers.GetRoutingRules)
+	authGroup.PUT("/routing-rules/:category", editor, handlers.UpdateRoutingRule)
+
+	// Background Mailchimp sync
+	authGroup.GET("/mailchimp-sync", viewer, handlers.GetMailchimpSyncCounts)
+	authGroup.POST("/contacts/:id/mailchimp-sync", editor, handlers.RetryMailchimpSync)
+
+	// Booking inquiries
+	authGroup.GET("/bookings", viewer, handlers.GetBookings)
+	authGroup.GET("/bookings/:id", viewer, handlers.GetBookingByID)
+
+	// Admin accounts
+	authGroup.GET("/admins", owner, handlers.GetAdmins)
+	authGroup.POST("/admins", owner, handlers.InviteAdmin)
+	authGroup.DELETE("/admins/:email", owner, handlers.RemoveAdmin)
+	authGroup.GET("/verification-failures", owner, handlers.GetVerificationFailures)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -123,3 +343,11 @@ func main() {
 
 	log.Println("Server exiting")
 }
+
+func mustParseLimit(name, value string) ratelimit.Limit {
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return limit
+}